@@ -0,0 +1,152 @@
+// Command eval runs a prompt version over an evaluation dataset and prints
+// the per-case scores. Runs are stored like those started through the API.
+//
+//	go run ./cmd/eval -dataset <id> -version <prompt-version-id> -scorers exact_match,contains_keywords
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/database"
+	"github.com/abeselom-personal/go-ai-service/internal/encryption"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/google/uuid"
+)
+
+func main() {
+	configDir := flag.String("config", "./config", "directory containing config.yml")
+	datasetID := flag.String("dataset", "", "dataset ID (required)")
+	versionID := flag.String("version", "", "prompt version ID to evaluate (required)")
+	provider := flag.String("provider", "", "provider override; requires -model")
+	model := flag.String("model", "", "model override; requires -provider")
+	scorerList := flag.String("scorers", "", "comma-separated scorer types without settings")
+	scorerFile := flag.String("scorers-file", "", "JSON file with a list of scorer configs")
+	concurrency := flag.Int("concurrency", 0, "requests in flight (default 4)")
+	flag.Parse()
+
+	if err := run(*configDir, *datasetID, *versionID, *provider, *model, *scorerList, *scorerFile, *concurrency); err != nil {
+		fmt.Fprintln(os.Stderr, "eval:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configDir, datasetID, versionID, provider, model, scorerList, scorerFile string, concurrency int) error {
+	dataset, err := uuid.Parse(datasetID)
+	if err != nil {
+		return fmt.Errorf("-dataset: %w", err)
+	}
+	version, err := uuid.Parse(versionID)
+	if err != nil {
+		return fmt.Errorf("-version: %w", err)
+	}
+	scorers, err := loadScorers(scorerList, scorerFile)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	keys, err := encryption.NewKeyring(cfg.Security)
+	if err != nil {
+		return err
+	}
+	models.UseFieldEncryption(keys, cfg.Security.EncryptUsageLogs)
+	db, err := database.NewPostgresDB(database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.Name,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	embeddings := service.NewEmbeddingService(db, cfg)
+	docs := service.NewDocumentService(repository.NewDocumentRepo(db), embeddings, cfg)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, docs)
+	evals := service.NewEvalService(repository.NewEvalRepo(db), prompts)
+
+	ctx := context.Background()
+	created, err := evals.CreateRun(ctx, &models.EvalRun{
+		DatasetID:       dataset,
+		PromptVersionID: version,
+		Provider:        provider,
+		ModelName:       model,
+		Scorers:         scorers,
+		Concurrency:     concurrency,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("run %s: %d cases\n", created.ID, created.CaseCount)
+
+	if _, err := evals.Execute(ctx, created.ID.String()); err != nil {
+		return err
+	}
+	detail, err := evals.GetRun(ctx, created.ID.String())
+	if err != nil {
+		return err
+	}
+	printRun(detail, scorers)
+	return nil
+}
+
+func loadScorers(list, file string) (models.ScorerConfigs, error) {
+	var scorers models.ScorerConfigs
+	if file != "" {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &scorers); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			scorers = append(scorers, models.ScorerConfig{Type: name})
+		}
+	}
+	if len(scorers) == 0 {
+		return nil, fmt.Errorf("no scorers given; use -scorers or -scorers-file")
+	}
+	return scorers, nil
+}
+
+func printRun(detail *service.EvalRunDetail, scorers models.ScorerConfigs) {
+	names := make([]string, len(scorers))
+	for i, s := range scorers {
+		names[i] = s.Type
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "CASE\t%s\tERROR\n", strings.Join(names, "\t"))
+	for _, r := range detail.Results {
+		fmt.Fprintf(w, "%s", r.CaseID)
+		for _, name := range names {
+			fmt.Fprintf(w, "\t%.2f", r.Scores[name])
+		}
+		fmt.Fprintf(w, "\t%s\n", r.Error)
+	}
+	fmt.Fprintf(w, "MEAN")
+	for _, name := range names {
+		fmt.Fprintf(w, "\t%.2f", detail.Run.Scores[name])
+	}
+	fmt.Fprintf(w, "\t%d failed\n", detail.Run.FailedCases)
+	w.Flush()
+}
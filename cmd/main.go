@@ -1,20 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"time"
 
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
 	"github.com/abeselom-personal/go-ai-service/internal/config"
 	"github.com/abeselom-personal/go-ai-service/internal/database"
+	"github.com/abeselom-personal/go-ai-service/internal/encryption"
 	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
 	"github.com/abeselom-personal/go-ai-service/internal/routes"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+const configDir = "./config"
+
 func main() {
 	// Load configuration
-	cfg, err := config.LoadConfig("./config")
+	cfg, err := config.LoadConfig(configDir)
 	if err != nil {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
@@ -23,6 +32,12 @@ func main() {
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
 
+	keys, err := encryption.NewKeyring(cfg.Security)
+	if err != nil {
+		logger.Fatal("invalid encryption keys", zap.Error(err))
+	}
+	models.UseFieldEncryption(keys, cfg.Security.EncryptUsageLogs)
+
 	// Setup database connection
 	db, err := database.NewPostgresDB(database.Config{
 		Host:     cfg.Database.Host,
@@ -38,9 +53,87 @@ func main() {
 
 	// Auto migrate if enabled
 	if cfg.Database.MigrationEnabled {
-		if err := db.AutoMigrate(&models.AIUsageLog{}, &models.RateLimit{}, &models.SystemPrompt{}); err != nil {
+		migrate := db.Set(models.EmbeddingDimensionsKey, cfg.Retrieval.Dimensions)
+		if err := migrate.AutoMigrate(models.All()...); err != nil {
 			logger.Fatal("failed to migrate database", zap.Error(err))
 		}
+		if err := repository.NewSystemPromptRepo(db).EnsureSearchIndex(context.Background()); err != nil {
+			logger.Fatal("failed to create prompt search index", zap.Error(err))
+		}
+	}
+
+	// Fail fast on a model that could not serve a single request
+	if cfg.Server.CheckModels {
+		providers := service.NewProviderService(repository.NewProviderRepo(db),
+			service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+		if err := providers.CheckModels(context.Background()); err != nil {
+			logger.Fatal("invalid model configuration", zap.Error(err))
+		}
+	}
+
+	// Reload provider and model settings when the config file changes
+	cfg.OnReload(func(changes []string) {
+		logger.Info("reloaded provider configuration", zap.Strings("changes", changes))
+	})
+	configSvc := service.NewConfigService(cfg, service.NewAuditService(repository.NewAuditRepo(db)))
+	err = cfg.Watch(func() {
+		ctx := audit.WithActor(context.Background(), audit.Actor{Name: "config watch"})
+		if _, err := configSvc.Reload(ctx); err != nil {
+			logger.Error("rejected config change", zap.Error(err))
+		}
+	})
+	if err != nil {
+		logger.Error("failed to watch config file", zap.Error(err))
+	}
+
+	// Sync the prompts defined in config/prompts, now and on every change
+	promptFiles := filepath.Join(configDir, "prompts")
+	filePrompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	res, err := filePrompts.SyncPromptFiles(context.Background(), promptFiles)
+	if err != nil {
+		logger.Fatal("failed to sync prompt files", zap.String("dir", promptFiles), zap.Error(err))
+	}
+	logPromptSync(logger, res)
+	err = filePrompts.WatchPromptFiles(context.Background(), promptFiles, func(res *service.ImportResult, err error) {
+		if err != nil {
+			logger.Error("failed to sync prompt files", zap.String("dir", promptFiles), zap.Error(err))
+			return
+		}
+		logPromptSync(logger, res)
+	})
+	if err != nil {
+		logger.Error("failed to watch prompt files", zap.String("dir", promptFiles), zap.Error(err))
+	}
+
+	// Reseal provider credentials left on an older encryption key version
+	go func() {
+		audit := service.NewAuditService(repository.NewAuditRepo(db))
+		creds := service.NewProviderCredentialService(repository.NewProviderCredentialRepo(db), audit,
+			service.NewProviderService(repository.NewProviderRepo(db), audit, cfg), cfg)
+		n, err := creds.Reencrypt(context.Background())
+		if err != nil {
+			logger.Error("failed to re-encrypt provider credentials", zap.Error(err), zap.Int("reencrypted", n))
+		} else if n > 0 {
+			logger.Info("re-encrypted provider credentials", zap.Int("count", n))
+		}
+	}()
+
+	// Purge prompts that have been in the trash longer than the retention
+	if days := cfg.Trash.RetentionDays; days > 0 {
+		go func() {
+			prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+			ticker := time.NewTicker(cfg.Trash.PurgeInterval)
+			defer ticker.Stop()
+			for {
+				n, err := prompts.PurgeDeleted(context.Background(), time.Duration(days)*24*time.Hour)
+				if err != nil {
+					logger.Error("failed to purge deleted prompts", zap.Error(err), zap.Int("purged", n))
+				} else if n > 0 {
+					logger.Info("purged deleted prompts", zap.Int("count", n))
+				}
+				<-ticker.C
+			}
+		}()
 	}
 
 	// Initialize Gin router
@@ -63,3 +156,10 @@ func main() {
 		logger.Fatal("server failed to start", zap.Error(err))
 	}
 }
+
+func logPromptSync(logger *zap.Logger, res *service.ImportResult) {
+	for _, c := range res.Changes {
+		logger.Info("synced prompt file", zap.String("action", c.Action), zap.String("kind", c.Kind),
+			zap.String("prompt", c.Key), zap.Strings("fields", c.Fields))
+	}
+}
@@ -0,0 +1,225 @@
+// Command prompts moves prompt bundles between files and a running service,
+// so prompts can be kept in git and synced from CI.
+//
+//	go run ./cmd/prompts export -url http://localhost:8080 > prompts.yml
+//	go run ./cmd/prompts sync -url http://localhost:8080 -dry-run prompts/
+//
+// The API key, which needs the admin scope, is read from -key or
+// AI_SERVICE_KEY. sync reads every .yml, .yaml and .json file under the
+// given paths and applies them as one bundle.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+)
+
+const usage = `usage: prompts <command> [flags]
+
+commands:
+  export   write the service's prompts as a bundle to stdout
+  sync     apply bundle files to the service`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = export(os.Args[2:])
+	case "sync":
+		err = sync(os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "prompts:", err)
+		os.Exit(1)
+	}
+}
+
+// client talks to the bundle endpoints of one service.
+type client struct {
+	baseURL string
+	key     string
+	http    *http.Client
+}
+
+func clientFlags(flags *flag.FlagSet) func() *client {
+	baseURL := flags.String("url", envOr("AI_SERVICE_URL", "http://localhost:8080"), "service base URL")
+	key := flags.String("key", os.Getenv("AI_SERVICE_KEY"), "admin API key")
+	return func() *client {
+		return &client{
+			baseURL: strings.TrimRight(*baseURL, "/"),
+			key:     *key,
+			http:    &http.Client{Timeout: 2 * time.Minute},
+		}
+	}
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (c *client) do(method, path string, query url.Values, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, c.baseURL+"/ai/api/system-prompts/"+path+"?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(out, &apiErr) == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("%s %s: %s", method, path, apiErr.Error)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return out, nil
+}
+
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	newClient := clientFlags(flags)
+	module := flags.String("module", "", "export only this module")
+	format := flags.String("format", service.BundleFormatYAML, "yaml or json")
+	flags.Parse(args)
+
+	query := url.Values{"format": {*format}}
+	if *module != "" {
+		query.Set("module_name", *module)
+	}
+	out, err := newClient().do(http.MethodGet, "export", query, nil)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+func sync(args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	newClient := clientFlags(flags)
+	module := flags.String("module", "", "limit the sync to this module")
+	mode := flags.String("mode", service.ImportUpsert, "upsert, or replace to also delete what the files lack")
+	dryRun := flags.Bool("dry-run", false, "only print the changes")
+	author := flags.String("author", "", "author recorded on new prompt versions")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return fmt.Errorf("sync: no bundle files or directories given")
+	}
+
+	bundle, err := readBundles(flags.Args())
+	if err != nil {
+		return err
+	}
+	body, err := bundle.Encode(service.BundleFormatJSON)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"mode": {*mode}}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+	if *module != "" {
+		query.Set("module_name", *module)
+	}
+	if *author != "" {
+		query.Set("author", *author)
+	}
+	out, err := newClient().do(http.MethodPost, "import", query, body)
+	if err != nil {
+		return err
+	}
+	var result service.ImportResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return err
+	}
+	printResult(&result)
+	return nil
+}
+
+// readBundles merges the bundle files found under paths.
+func readBundles(paths []string) (*service.Bundle, error) {
+	merged := &service.Bundle{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			switch filepath.Ext(path) {
+			case ".yml", ".yaml", ".json":
+			default:
+				return nil
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			b, err := service.ParseBundle(raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if merged.Version != 0 && b.Version != merged.Version {
+				return fmt.Errorf("%s: bundle version %d differs from %d", path, b.Version, merged.Version)
+			}
+			merged.Merge(b)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+func printResult(result *service.ImportResult) {
+	marks := map[string]string{"create": "+", "update": "~", "delete": "-"}
+	for _, c := range result.Changes {
+		line := fmt.Sprintf("%s %s %s", marks[c.Action], c.Kind, c.Key)
+		if len(c.Fields) > 0 {
+			line += " (" + strings.Join(c.Fields, ", ") + ")"
+		}
+		fmt.Println(line)
+		for _, d := range c.Diff {
+			if d.Op != " " {
+				fmt.Printf("    %s %s\n", d.Op, d.Text)
+			}
+		}
+	}
+	summary := fmt.Sprintf("%d changed, %d unchanged", len(result.Changes), result.Unchanged)
+	if result.DryRun {
+		summary += " (dry run, nothing applied)"
+	}
+	fmt.Println(summary)
+}
@@ -0,0 +1,72 @@
+// Command rotate-keys re-encrypts everything stored sealed so that it
+// uses the current encryption key: provider credentials and, depending on
+// security.encrypt_usage_logs, usage log bodies. Run it after bumping
+// security.encryption_key_version with the old key still listed in
+// security.previous_keys; once it finishes the old key can be removed.
+// Turning encrypt_usage_logs on or off and running it encrypts or
+// decrypts existing logs.
+//
+//	go run ./cmd/rotate-keys -batch 500
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/database"
+	"github.com/abeselom-personal/go-ai-service/internal/encryption"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+)
+
+func main() {
+	configDir := flag.String("config", "./config", "directory containing config.yml")
+	batch := flag.Int("batch", 500, "usage logs rewritten per query")
+	flag.Parse()
+
+	if err := run(*configDir, *batch); err != nil {
+		fmt.Fprintln(os.Stderr, "rotate-keys:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configDir string, batch int) error {
+	cfg, err := config.LoadConfig(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	keys, err := encryption.NewKeyring(cfg.Security)
+	if err != nil {
+		return err
+	}
+	models.UseFieldEncryption(keys, cfg.Security.EncryptUsageLogs)
+
+	db, err := database.NewPostgresDB(database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.Name,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	ctx := context.Background()
+	audit := service.NewAuditService(repository.NewAuditRepo(db))
+	creds, err := service.NewProviderCredentialService(repository.NewProviderCredentialRepo(db), audit,
+		service.NewProviderService(repository.NewProviderRepo(db), audit, cfg), cfg).Reencrypt(ctx)
+	fmt.Printf("provider credentials: %d re-encrypted\n", creds)
+	if err != nil {
+		return err
+	}
+	logs, err := service.NewUsageService(repository.NewUsageRepo(db)).ResealLogs(ctx, batch)
+	fmt.Printf("usage logs: %d rewritten (key version %d, encrypt_usage_logs=%t)\n",
+		logs, keys.Version(), cfg.Security.EncryptUsageLogs)
+	return err
+}
@@ -0,0 +1,28 @@
+// Package audit carries who is making a request, and from where, through
+// request contexts so administrative changes can be attributed.
+package audit
+
+import "context"
+
+// Actor identifies the caller of a request. ID is the API key ID, empty
+// for the bootstrap key and JWT callers; Name is the key name or JWT
+// subject. Every field is empty when unknown.
+type Actor struct {
+	ID        string
+	Name      string
+	IP        string
+	RequestID string
+}
+
+type contextKey struct{}
+
+// WithActor returns a copy of ctx acting on behalf of a.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, a)
+}
+
+// ActorFrom returns the actor of ctx, or the zero Actor.
+func ActorFrom(ctx context.Context) Actor {
+	a, _ := ctx.Value(contextKey{}).(Actor)
+	return a
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,9 +14,17 @@ type Config struct {
 	Server    ServerConfig
 	Database  DatabaseConfig
 	Security  SecurityConfig
+	Auth      AuthConfig
 	Defaults  DefaultConfig
 	Logging   LoggingConfig
 	RateLimit RateLimitConfig
+	Retrieval RetrievalConfig
+	Redaction RedactionConfig
+	Guardrail GuardrailConfig
+	Trash     TrashConfig
+
+	// reload holds the state behind CurrentDefaults and Reload.
+	reload reloadState
 }
 
 type ServerConfig struct {
@@ -23,6 +32,9 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	// CheckModels stops startup when a model's request template or
+	// response paths are invalid, instead of failing the first request.
+	CheckModels bool `mapstructure:"check_models"`
 }
 
 type DatabaseConfig struct {
@@ -41,6 +53,41 @@ type DatabaseConfig struct {
 type SecurityConfig struct {
 	EncryptionKey        string `mapstructure:"encryption_key"`
 	EncryptionKeyVersion int    `mapstructure:"encryption_key_version"`
+	// EncryptUsageLogs stores usage log request and response bodies
+	// encrypted with the current key.
+	EncryptUsageLogs bool `mapstructure:"encrypt_usage_logs"`
+	// PreviousKeys keep values sealed before a key rotation readable
+	// until they have been re-encrypted with the current key.
+	PreviousKeys []PreviousKey `mapstructure:"previous_keys"`
+}
+
+type PreviousKey struct {
+	Version int    `mapstructure:"version"`
+	Key     string `mapstructure:"key"`
+}
+
+type AuthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BootstrapKey is accepted as a global admin key; set it from the
+	// environment to issue the first API keys.
+	BootstrapKey string    `mapstructure:"bootstrap_key"`
+	JWT          JWTConfig `mapstructure:"jwt"`
+}
+
+// JWTConfig enables bearer JWTs from an identity provider alongside API
+// keys. Tokens are checked against JWKSURL, or StaticKey when no JWKS is
+// configured.
+type JWTConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	JWKSURL     string        `mapstructure:"jwks_url"`
+	JWKSRefresh time.Duration `mapstructure:"jwks_refresh"`
+	StaticKey   string        `mapstructure:"static_key"` // PEM public key or HMAC secret
+	Issuer      string        `mapstructure:"issuer"`
+	Audience    string        `mapstructure:"audience"`
+	TenantClaim string        `mapstructure:"tenant_claim"`
+	ModuleClaim string        `mapstructure:"module_claim"`
+	ScopesClaim string        `mapstructure:"scopes_claim"`
+	ScopePrefix string        `mapstructure:"scope_prefix"`
 }
 
 type DefaultConfig struct {
@@ -56,6 +103,8 @@ type ProviderConfig struct {
 	Default    bool          `mapstructure:"default"`
 	Models     []ModelConfig `mapstructure:"models"`
 	AuthMethod string        `mapstructure:"auth_method"` // "header" or "query_param"
+	// EmbeddingModel is the model used for /embeddings requests routed to this provider.
+	EmbeddingModel string `mapstructure:"embedding_model"`
 }
 
 type ModelConfig struct {
@@ -63,6 +112,12 @@ type ModelConfig struct {
 	Parameters   string `mapstructure:"parameters"`
 	Config       string `mapstructure:"config"`
 	ResponsePath string `mapstructure:"response_path"`
+	// Token usage paths in the response; when unset tokens are estimated.
+	PromptTokensPath     string `mapstructure:"prompt_tokens_path"`
+	CompletionTokensPath string `mapstructure:"completion_tokens_path"`
+	// Pricing in USD per 1000 tokens.
+	InputCostPer1K  float64 `mapstructure:"input_cost_per_1k"`
+	OutputCostPer1K float64 `mapstructure:"output_cost_per_1k"`
 }
 
 type LoggingConfig struct {
@@ -77,6 +132,90 @@ type RateLimitConfig struct {
 	IPWhitelist []string `mapstructure:"ip_whitelist"`
 }
 
+type RetrievalConfig struct {
+	ChunkSize    int `mapstructure:"chunk_size"`    // characters per chunk
+	ChunkOverlap int `mapstructure:"chunk_overlap"` // characters shared by neighbouring chunks
+	TopK         int `mapstructure:"top_k"`
+	// Dimensions is the length of the vectors the embedding model returns;
+	// document chunk embeddings are stored as vector(dimensions).
+	Dimensions int `mapstructure:"dimensions"`
+}
+
+// RedactionConfig removes PII from prompts before they reach a provider
+// and from everything written to the usage log.
+type RedactionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Policies apply per module; the policy with an empty module applies
+	// to every module without its own.
+	Policies []RedactionPolicy `mapstructure:"policies"`
+}
+
+type RedactionPolicy struct {
+	Module string `mapstructure:"module"`
+	// Action is "redact" (numbered placeholders), "mask" (hide all but a
+	// few characters), "block" (reject the request) or "off".
+	Action string `mapstructure:"action"`
+	// Entities limits the built-in detectors (email, phone, credit_card);
+	// empty enables all of them.
+	Entities []string           `mapstructure:"entities"`
+	Patterns []RedactionPattern `mapstructure:"patterns"`
+	// RestoreResponse puts the original values back in place of
+	// placeholders the model echoed, in the response returned to callers.
+	RestoreResponse bool `mapstructure:"restore_response"`
+}
+
+// RedactionPattern is a custom detector; Name becomes the entity name.
+type RedactionPattern struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+// GuardrailConfig checks user prompts and model output against per-module
+// content policies.
+type GuardrailConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Policies apply per module; the policy with an empty module applies
+	// to every module without its own.
+	Policies []GuardrailPolicy `mapstructure:"policies"`
+}
+
+type GuardrailPolicy struct {
+	Module string `mapstructure:"module"`
+	// Action is "block" (reject), "warn" (record and continue), "rewrite"
+	// (remove what matched, truncate what is too long) or "off".
+	Action    string          `mapstructure:"action"`
+	Blocklist []string        `mapstructure:"blocklist"` // case-insensitive words or phrases
+	Rules     []GuardrailRule `mapstructure:"rules"`
+	// Lengths are in characters; zero means unlimited.
+	MaxInputLength  int `mapstructure:"max_input_length"`
+	MaxOutputLength int `mapstructure:"max_output_length"`
+	// PromptInjection flags common injection phrasing in user prompts.
+	PromptInjection bool             `mapstructure:"prompt_injection"`
+	Moderation      ModerationConfig `mapstructure:"moderation"`
+}
+
+type GuardrailRule struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+// ModerationConfig asks a configured model to classify text as safe or
+// unsafe.
+type ModerationConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"`
+}
+
+// TrashConfig controls how long deleted prompts can be restored before the
+// scheduled purge removes them for good.
+type TrashConfig struct {
+	// RetentionDays is how long prompts stay in the trash; zero keeps
+	// them until purged by hand.
+	RetentionDays int           `mapstructure:"retention_days"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	v := viper.New()
 
@@ -93,6 +232,12 @@ func LoadConfig(path string) (*Config, error) {
 	v.SetDefault("database.conn_max_lifetime", time.Hour)
 	v.SetDefault("database.migration_enabled", true)
 
+	v.SetDefault("auth.enabled", true)
+	v.SetDefault("auth.jwt.jwks_refresh", time.Hour)
+	v.SetDefault("auth.jwt.tenant_claim", "tenant")
+	v.SetDefault("auth.jwt.module_claim", "module")
+	v.SetDefault("auth.jwt.scopes_claim", "scope")
+
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 
@@ -100,6 +245,17 @@ func LoadConfig(path string) (*Config, error) {
 	v.SetDefault("rate_limit.requests", 100)
 	v.SetDefault("rate_limit.window", "1m")
 
+	v.SetDefault("retrieval.chunk_size", 1000)
+	v.SetDefault("retrieval.chunk_overlap", 200)
+	v.SetDefault("retrieval.top_k", 4)
+	v.SetDefault("retrieval.dimensions", 768)
+
+	v.SetDefault("redaction.enabled", false)
+	v.SetDefault("guardrail.enabled", false)
+
+	v.SetDefault("trash.retention_days", 30)
+	v.SetDefault("trash.purge_interval", 24*time.Hour)
+
 	// Bind environment variables to config paths
 	_ = v.BindEnv("server.port", "PORT")
 	_ = v.BindEnv("server.read_timeout", "READ_TIMEOUT")
@@ -119,6 +275,15 @@ func LoadConfig(path string) (*Config, error) {
 
 	_ = v.BindEnv("security.encryption_key", "ENCRYPTION_KEY")
 	_ = v.BindEnv("security.encryption_key_version", "ENCRYPTION_KEY_VERSION")
+	_ = v.BindEnv("security.encrypt_usage_logs", "ENCRYPT_USAGE_LOGS")
+
+	_ = v.BindEnv("auth.enabled", "AUTH_ENABLED")
+	_ = v.BindEnv("auth.bootstrap_key", "AUTH_BOOTSTRAP_KEY")
+	_ = v.BindEnv("auth.jwt.enabled", "AUTH_JWT_ENABLED")
+	_ = v.BindEnv("auth.jwt.jwks_url", "AUTH_JWT_JWKS_URL")
+	_ = v.BindEnv("auth.jwt.static_key", "AUTH_JWT_STATIC_KEY")
+	_ = v.BindEnv("auth.jwt.issuer", "AUTH_JWT_ISSUER")
+	_ = v.BindEnv("auth.jwt.audience", "AUTH_JWT_AUDIENCE")
 
 	_ = v.BindEnv("defaults.provider", "DEFAULT_PROVIDER")
 	_ = v.BindEnv("defaults.model", "DEFAULT_MODEL")
@@ -130,6 +295,10 @@ func LoadConfig(path string) (*Config, error) {
 	_ = v.BindEnv("rate_limit.requests", "RATE_LIMIT_REQUESTS")
 	_ = v.BindEnv("rate_limit.window", "RATE_LIMIT_WINDOW")
 	_ = v.BindEnv("rate_limit.ip_whitelist", "RATE_LIMIT_IP_WHITELIST")
+
+	_ = v.BindEnv("redaction.enabled", "REDACTION_ENABLED")
+	_ = v.BindEnv("guardrail.enabled", "GUARDRAIL_ENABLED")
+	_ = v.BindEnv("trash.retention_days", "TRASH_RETENTION_DAYS")
 	// Configuration sources
 	v.AddConfigPath(path)
 	v.SetConfigName("config")
@@ -154,9 +323,17 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	// <NAME>_API_KEY takes precedence over the file, which may refer to
+	// other variables as ${VAR}. Keys written in the file itself can be
+	// rotated with a reload.
 	for i := range cfg.Defaults.Providers {
-		cfg.Defaults.Providers[i].APIKey = os.Getenv(fmt.Sprintf("%s_API_KEY", strings.ToUpper(cfg.Defaults.Providers[i].Name)))
+		p := &cfg.Defaults.Providers[i]
+		p.APIKey = os.ExpandEnv(p.APIKey)
+		if key := os.Getenv(fmt.Sprintf("%s_API_KEY", strings.ToUpper(p.Name))); key != "" {
+			p.APIKey = key
+		}
 	}
+	cfg.reload.dir = path
 	return &cfg, nil
 }
 
@@ -165,7 +342,6 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("encryption key is required")
 	}
 
-	fmt.Println(cfg.Security.EncryptionKey)
 	if len(cfg.Security.EncryptionKey) != 32 {
 		return fmt.Errorf("encryption key must be 32 bytes")
 	}
@@ -182,9 +358,91 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("database name is required")
 	}
 
+	for _, p := range cfg.Redaction.Policies {
+		switch p.Action {
+		case "redact", "mask", "block", "off":
+		default:
+			return fmt.Errorf("redaction policy %q: unknown action %q", p.Module, p.Action)
+		}
+		for _, pat := range p.Patterns {
+			if pat.Name == "" {
+				return fmt.Errorf("redaction policy %q: pattern needs a name", p.Module)
+			}
+			if _, err := regexp.Compile(pat.Regex); err != nil {
+				return fmt.Errorf("redaction policy %q: pattern %s: %w", p.Module, pat.Name, err)
+			}
+		}
+	}
+
+	for _, p := range cfg.Guardrail.Policies {
+		switch p.Action {
+		case "block", "warn", "rewrite", "off":
+		default:
+			return fmt.Errorf("guardrail policy %q: unknown action %q", p.Module, p.Action)
+		}
+		for _, r := range p.Rules {
+			if r.Name == "" {
+				return fmt.Errorf("guardrail policy %q: rule needs a name", p.Module)
+			}
+			if _, err := regexp.Compile(r.Regex); err != nil {
+				return fmt.Errorf("guardrail policy %q: rule %s: %w", p.Module, r.Name, err)
+			}
+		}
+		if p.Moderation.Enabled && (p.Moderation.Provider == "" || p.Moderation.Model == "") {
+			return fmt.Errorf("guardrail policy %q: moderation needs a provider and model", p.Module)
+		}
+	}
+
+	if cfg.Trash.RetentionDays < 0 {
+		return fmt.Errorf("trash retention_days must not be negative")
+	}
+	if cfg.Trash.RetentionDays > 0 && cfg.Trash.PurgeInterval <= 0 {
+		return fmt.Errorf("trash purge_interval must be positive")
+	}
+
 	if cfg.Defaults.Provider == "" && len(cfg.Defaults.Providers) > 0 {
 		cfg.Defaults.Provider = cfg.Defaults.Providers[0].Name
 	}
+	if err := validateDefaults(&cfg.Defaults); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// validateDefaults checks the provider and model settings, which unlike
+// the rest of the config can change at runtime through Reload.
+func validateDefaults(d *DefaultConfig) error {
+	providers := make(map[string]bool)
+	activeModel := d.Model == ""
+	for _, p := range d.Providers {
+		if p.Name == "" {
+			return fmt.Errorf("every provider needs a name")
+		}
+		if providers[p.Name] {
+			return fmt.Errorf("provider %s is listed twice", p.Name)
+		}
+		providers[p.Name] = true
+		if p.BaseURL == "" {
+			return fmt.Errorf("provider %s needs a base_url", p.Name)
+		}
+		models := make(map[string]bool)
+		for _, m := range p.Models {
+			if m.Name == "" {
+				return fmt.Errorf("provider %s: every model needs a name", p.Name)
+			}
+			if models[m.Name] {
+				return fmt.Errorf("provider %s: model %s is listed twice", p.Name, m.Name)
+			}
+			models[m.Name] = true
+			activeModel = activeModel || m.Name == d.Model
+		}
+	}
+	if d.Provider != "" && len(d.Providers) > 0 && !providers[d.Provider] {
+		return fmt.Errorf("default provider %s is not configured", d.Provider)
+	}
+	if !activeModel && len(d.Providers) > 0 {
+		return fmt.Errorf("default model %s is not offered by any provider", d.Model)
+	}
+	return nil
+}
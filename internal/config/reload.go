@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// reloadState lets the provider and model settings be replaced while
+// requests are using them. Readers get an immutable snapshot from
+// CurrentDefaults; Reload builds a new one and swaps the pointer.
+type reloadState struct {
+	dir       string
+	defaults  atomic.Pointer[DefaultConfig]
+	mu        sync.Mutex // serializes reloads
+	listeners []func(changes []string)
+}
+
+// CurrentDefaults returns the provider and model settings in effect. The
+// result is never modified; a reload replaces it instead, so a request
+// that keeps using one snapshot sees consistent settings throughout.
+func (c *Config) CurrentDefaults() *DefaultConfig {
+	if d := c.reload.defaults.Load(); d != nil {
+		return d
+	}
+	return &c.Defaults
+}
+
+// OnReload registers fn to be called with the changes of every reload
+// that changed something.
+func (c *Config) OnReload(fn func(changes []string)) {
+	c.reload.mu.Lock()
+	defer c.reload.mu.Unlock()
+	c.reload.listeners = append(c.reload.listeners, fn)
+}
+
+// Reload reads the config directory again and, if the result is valid,
+// swaps in its provider and model settings. Other settings only take
+// effect on restart. It returns what changed.
+func (c *Config) Reload() ([]string, error) {
+	c.reload.mu.Lock()
+	defer c.reload.mu.Unlock()
+	if c.reload.dir == "" {
+		return nil, fmt.Errorf("config was not loaded from a directory")
+	}
+
+	next, err := LoadConfig(c.reload.dir)
+	if err != nil {
+		return nil, err
+	}
+	changes := diffDefaults(c.CurrentDefaults(), &next.Defaults)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	c.reload.defaults.Store(&next.Defaults)
+	for _, fn := range c.reload.listeners {
+		fn(changes)
+	}
+	return changes, nil
+}
+
+// Watch calls onChange whenever the config file is written. It is meant
+// to trigger Reload; the file may still be half written, in which case
+// the reload fails validation and the next write triggers another.
+func (c *Config) Watch(onChange func()) error {
+	if c.reload.dir == "" {
+		return fmt.Errorf("config was not loaded from a directory")
+	}
+	v := viper.New()
+	v.AddConfigPath(c.reload.dir)
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	v.OnConfigChange(func(fsnotify.Event) { onChange() })
+	v.WatchConfig()
+	return nil
+}
+
+// diffDefaults describes how next differs from prev. API keys are
+// reported as changed, never shown.
+func diffDefaults(prev, next *DefaultConfig) []string {
+	var changes []string
+	if prev.Provider != next.Provider {
+		changes = append(changes, fmt.Sprintf("default provider: %s -> %s", prev.Provider, next.Provider))
+	}
+	if prev.Model != next.Model {
+		changes = append(changes, fmt.Sprintf("default model: %s -> %s", prev.Model, next.Model))
+	}
+
+	old := make(map[string]*ProviderConfig)
+	for i := range prev.Providers {
+		old[prev.Providers[i].Name] = &prev.Providers[i]
+	}
+	for i := range next.Providers {
+		p := &next.Providers[i]
+		o, ok := old[p.Name]
+		delete(old, p.Name)
+		if !ok {
+			changes = append(changes, fmt.Sprintf("provider %s added", p.Name))
+			continue
+		}
+		for _, f := range []struct {
+			name    string
+			changed bool
+		}{
+			{"base_url", o.BaseURL != p.BaseURL},
+			{"api_key", o.APIKey != p.APIKey},
+			{"auth_method", o.AuthMethod != p.AuthMethod},
+			{"default", o.Default != p.Default},
+			{"embedding_model", o.EmbeddingModel != p.EmbeddingModel},
+		} {
+			if f.changed {
+				changes = append(changes, fmt.Sprintf("provider %s: %s changed", p.Name, f.name))
+			}
+		}
+		changes = append(changes, diffModels(p.Name, o.Models, p.Models)...)
+	}
+	for _, p := range prev.Providers {
+		if _, removed := old[p.Name]; removed {
+			changes = append(changes, fmt.Sprintf("provider %s removed", p.Name))
+		}
+	}
+	return changes
+}
+
+func diffModels(provider string, prev, next []ModelConfig) []string {
+	var changes []string
+	old := make(map[string]ModelConfig)
+	for _, m := range prev {
+		old[m.Name] = m
+	}
+	for _, m := range next {
+		o, ok := old[m.Name]
+		delete(old, m.Name)
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("model %s/%s added", provider, m.Name))
+		case o != m:
+			changes = append(changes, fmt.Sprintf("model %s/%s changed", provider, m.Name))
+		}
+	}
+	for _, m := range prev {
+		if _, removed := old[m.Name]; removed {
+			changes = append(changes, fmt.Sprintf("model %s/%s removed", provider, m.Name))
+		}
+	}
+	return changes
+}
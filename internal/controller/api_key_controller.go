@@ -0,0 +1,121 @@
+// controller/api_key_controller.go
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/middleware"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type APIKeyController struct {
+	svc *service.APIKeyService
+}
+
+func NewAPIKeyController(svc *service.APIKeyService) *APIKeyController {
+	return &APIKeyController{svc}
+}
+
+// Issue creates a key and returns its secret, which is never shown again.
+func (c *APIKeyController) Issue(ctx *gin.Context) {
+	var req struct {
+		Name       string     `json:"name" binding:"required"`
+		TenantID   string     `json:"tenant_id"`
+		ModuleName string     `json:"module_name"`
+		Scopes     []string   `json:"scopes" binding:"required,min=1"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	module, ok := moduleFor(ctx, req.ModuleName)
+	if !ok {
+		return
+	}
+
+	// Only the bootstrap key may issue keys for another tenant.
+	var issueCtx context.Context = ctx
+	if req.TenantID != "" && req.TenantID != tenant.FromContext(ctx) {
+		if p := middleware.PrincipalFrom(ctx); p != nil && p.TenantID != "" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "cannot issue keys for another tenant"})
+			return
+		}
+		issueCtx = tenant.WithTenant(ctx, req.TenantID)
+	}
+
+	issued, err := c.svc.Issue(issueCtx, req.Name, module, req.Scopes, req.ExpiresAt)
+	if errors.Is(err, service.ErrInvalidAPIKey) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, issued)
+}
+
+func (c *APIKeyController) List(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	keys, err := c.svc.List(ctx, module)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, keys)
+}
+
+func (c *APIKeyController) Rotate(ctx *gin.Context) {
+	if !c.authorizeKey(ctx) {
+		return
+	}
+	issued, err := c.svc.Rotate(ctx, ctx.Param("id"))
+	if errors.Is(err, service.ErrInvalidAPIKey) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, issued)
+}
+
+func (c *APIKeyController) Revoke(ctx *gin.Context) {
+	if !c.authorizeKey(ctx) {
+		return
+	}
+	if err := c.svc.Revoke(ctx, ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// authorizeKey keeps module-bound admins to their own module's keys.
+func (c *APIKeyController) authorizeKey(ctx *gin.Context) bool {
+	key, err := c.svc.Get(ctx, ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if p := middleware.PrincipalFrom(ctx); p != nil && p.Module != "" && p.Module != key.ModuleName {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "API key belongs to another module"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+// controller/audit_controller.go
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	svc *service.AuditService
+}
+
+func NewAuditController(svc *service.AuditService) *AuditController {
+	return &AuditController{svc}
+}
+
+// List returns audit events, newest first, filtered by the module_name,
+// actor, action, resource_type, resource_id, request_id, from, to and
+// limit query parameters.
+func (c *AuditController) List(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	filter := repository.AuditFilter{
+		Module:       module,
+		Actor:        ctx.Query("actor"),
+		Action:       ctx.Query("action"),
+		ResourceType: ctx.Query("resource_type"),
+		ResourceID:   ctx.Query("resource_id"),
+		RequestID:    ctx.Query("request_id"),
+	}
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := ctx.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC3339 timestamp"})
+			return
+		}
+		*dst = &t
+	}
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := c.svc.List(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,49 @@
+// controller/auth.go
+package controller
+
+import (
+	"net/http"
+
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	"github.com/abeselom-personal/go-ai-service/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// moduleFor returns the module a request acts on. Keys bound to a module
+// always act on it, and asking for another one is rejected with 403.
+// Without such a key the requested module is used as given.
+func moduleFor(ctx *gin.Context, requested string) (string, bool) {
+	p := middleware.PrincipalFrom(ctx)
+	if p == nil || p.Module == "" {
+		return requested, true
+	}
+	if requested != "" && requested != p.Module {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "key is not valid for module " + requested})
+		return "", false
+	}
+	return p.Module, true
+}
+
+// authorizeModule responds with 404 and notFound and returns false unless
+// moduleOf loads the requested row and the caller may act on its module.
+// Rows of other modules look missing, so their IDs give nothing away.
+// Keys not bound to a module skip the lookup.
+func authorizeModule(ctx *gin.Context, notFound string, moduleOf func() (string, error)) bool {
+	p := middleware.PrincipalFrom(ctx)
+	if p == nil || p.Module == "" {
+		return true
+	}
+	module, err := moduleOf()
+	if err != nil || !p.CanAccessModule(module) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return false
+	}
+	return true
+}
+
+// actorName is who a request acts for: the name of its API key or its JWT
+// subject, empty when auth is disabled. Prompt versions and labels record
+// it as their author; clients cannot claim to be someone else.
+func actorName(ctx *gin.Context) string {
+	return audit.ActorFrom(ctx.Request.Context()).Name
+}
@@ -0,0 +1,86 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/controller"
+	"github.com/abeselom-personal/go-ai-service/internal/middleware"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleBoundKeysOnlySeeTheirModule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := &config.Config{Auth: config.AuthConfig{Enabled: true}}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	docs := service.NewDocumentService(repository.NewDocumentRepo(db), nil, cfg)
+	ctrl := controller.NewDocumentController(docs)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	auth := middleware.NewAuth(keys, nil, cfg)
+	r.GET("/collections/:id/documents", auth.Require(models.ScopePromptsRead), ctrl.ListDocuments)
+	r.DELETE("/collections/:id", auth.Require(models.ScopePromptsWrite), ctrl.DeleteCollection)
+
+	ctx := context.Background()
+	billing, err := docs.CreateCollection(ctx, "billing", "faq", "")
+	require.NoError(t, err)
+	support, err := keys.Issue(ctx, "support", "support", []string{models.ScopePromptsRead, models.ScopePromptsWrite}, nil)
+	require.NoError(t, err)
+	owner, err := keys.Issue(ctx, "billing", "billing", []string{models.ScopePromptsRead}, nil)
+	require.NoError(t, err)
+
+	do := func(method, path, key string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	path := "/collections/" + billing.ID.String()
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, path+"/documents", support.Secret))
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, path, support.Secret))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, path+"/documents", owner.Secret))
+}
+
+func TestAuthorsComeFromTheAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := &config.Config{Auth: config.AuthConfig{Enabled: true}}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctrl := controller.NewSystemPromptController(prompts)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	auth := middleware.NewAuth(keys, nil, cfg)
+	r.PUT("/prompts/:id/labels/:label", auth.Require(models.ScopePromptsWrite), ctrl.SetLabel)
+
+	ctx := context.Background()
+	sp, err := prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "m", SystemPrompt: "Hi."}, "", "")
+	require.NoError(t, err)
+	key, err := keys.Issue(ctx, "release-bot", "support", []string{models.ScopePromptsWrite}, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/prompts/"+sp.ID.String()+"/labels/production",
+		strings.NewReader(`{"version": 1, "actor": "someone-else"}`))
+	req.Header.Set("X-API-Key", key.Secret)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	label, err := repository.NewSystemPromptRepo(db).GetLabel(ctx, sp.ID.String(), models.LabelProduction)
+	require.NoError(t, err)
+	assert.Equal(t, "release-bot", label.UpdatedBy, "the actor in the body is ignored")
+}
@@ -0,0 +1,31 @@
+// controller/config_controller.go
+package controller
+
+import (
+	"net/http"
+
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ConfigController struct {
+	svc *service.ConfigService
+}
+
+func NewConfigController(svc *service.ConfigService) *ConfigController {
+	return &ConfigController{svc}
+}
+
+// Reload re-reads the provider and model configuration and lists what
+// changed. An invalid config is rejected and the running one kept.
+func (c *ConfigController) Reload(ctx *gin.Context) {
+	changes, err := c.svc.Reload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if changes == nil {
+		changes = []string{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"changes": changes})
+}
@@ -0,0 +1,123 @@
+// controller/document_controller.go
+package controller
+
+import (
+	"net/http"
+
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentController struct {
+	svc *service.DocumentService
+}
+
+func NewDocumentController(svc *service.DocumentService) *DocumentController {
+	return &DocumentController{svc}
+}
+
+func (c *DocumentController) CreateCollection(ctx *gin.Context) {
+	var req struct {
+		ModuleName  string `json:"module_name" binding:"required"`
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := moduleFor(ctx, req.ModuleName); !ok {
+		return
+	}
+	collection, err := c.svc.CreateCollection(ctx, req.ModuleName, req.Name, req.Description)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, collection)
+}
+
+func (c *DocumentController) ListCollections(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	collections, err := c.svc.ListCollections(ctx, module)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, collections)
+}
+
+// authorizeCollection checks the collection in the :id parameter against
+// the caller's module.
+func (c *DocumentController) authorizeCollection(ctx *gin.Context) bool {
+	return authorizeModule(ctx, "Collection not found", func() (string, error) {
+		collection, err := c.svc.GetCollection(ctx, ctx.Param("id"))
+		return collection.ModuleName, err
+	})
+}
+
+func (c *DocumentController) DeleteCollection(ctx *gin.Context) {
+	if !c.authorizeCollection(ctx) {
+		return
+	}
+	if err := c.svc.DeleteCollection(ctx, ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *DocumentController) AddDocument(ctx *gin.Context) {
+	var req struct {
+		Title   string `json:"title" binding:"required"`
+		Content string `json:"content" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !c.authorizeCollection(ctx) {
+		return
+	}
+	doc, err := c.svc.AddDocument(ctx, ctx.Param("id"), req.Title, req.Content)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, doc)
+}
+
+func (c *DocumentController) ListDocuments(ctx *gin.Context) {
+	if !c.authorizeCollection(ctx) {
+		return
+	}
+	docs, err := c.svc.ListDocuments(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, docs)
+}
+
+func (c *DocumentController) Search(ctx *gin.Context) {
+	var req struct {
+		Query string `json:"query" binding:"required"`
+		TopK  int    `json:"top_k"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !c.authorizeCollection(ctx) {
+		return
+	}
+	chunks, err := c.svc.SearchByID(ctx, ctx.Param("id"), req.Query, req.TopK)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, chunks)
+}
@@ -0,0 +1,48 @@
+// controller/embedding_controller.go
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type EmbeddingController struct {
+	svc *service.EmbeddingService
+}
+
+func NewEmbeddingController(svc *service.EmbeddingService) *EmbeddingController {
+	return &EmbeddingController{svc}
+}
+
+func (c *EmbeddingController) Create(ctx *gin.Context) {
+	var req struct {
+		// ModuleName is taken from the API key when it is bound to a module.
+		ModuleName string   `json:"module_name"`
+		Texts      []string `json:"texts" binding:"required,min=1,dive,required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	module, ok := moduleFor(ctx, req.ModuleName)
+	if !ok {
+		return
+	}
+	if module == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "module_name is required"})
+		return
+	}
+
+	// Get cache control parameter
+	bypassCache, _ := strconv.ParseBool(ctx.Query("cache"))
+
+	result, err := c.svc.Embed(ctx, module, req.Texts, bypassCache)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,195 @@
+// controller/eval_controller.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type EvalController struct {
+	svc *service.EvalService
+}
+
+func NewEvalController(svc *service.EvalService) *EvalController {
+	return &EvalController{svc}
+}
+
+type evalCaseRequest struct {
+	Input     string                 `json:"input" binding:"required"`
+	Variables map[string]interface{} `json:"variables"`
+	Expected  string                 `json:"expected"`
+	Keywords  []string               `json:"keywords"`
+}
+
+func (r evalCaseRequest) toModel() models.EvalCase {
+	return models.EvalCase{
+		Input:     r.Input,
+		Variables: r.Variables,
+		Expected:  r.Expected,
+		Keywords:  r.Keywords,
+	}
+}
+
+func (c *EvalController) CreateDataset(ctx *gin.Context) {
+	var req struct {
+		ModuleName  string            `json:"module_name" binding:"required"`
+		Name        string            `json:"name" binding:"required"`
+		Description string            `json:"description"`
+		Cases       []evalCaseRequest `json:"cases" binding:"dive"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := moduleFor(ctx, req.ModuleName); !ok {
+		return
+	}
+
+	dataset := &models.EvalDataset{ModuleName: req.ModuleName, Name: req.Name, Description: req.Description}
+	for _, cr := range req.Cases {
+		dataset.Cases = append(dataset.Cases, cr.toModel())
+	}
+
+	created, err := c.svc.CreateDataset(ctx, dataset)
+	if errors.Is(err, service.ErrInvalidEval) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (c *EvalController) ListDatasets(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	datasets, err := c.svc.ListDatasets(ctx, module)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, datasets)
+}
+
+// authorizeDataset checks dataset id against the caller's module.
+func (c *EvalController) authorizeDataset(ctx *gin.Context, id string) bool {
+	return authorizeModule(ctx, "Dataset not found", func() (string, error) {
+		d, err := c.svc.GetDataset(ctx, id)
+		return d.ModuleName, err
+	})
+}
+
+func (c *EvalController) GetDataset(ctx *gin.Context) {
+	if !c.authorizeDataset(ctx, ctx.Param("id")) {
+		return
+	}
+	dataset, err := c.svc.GetDataset(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Dataset not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, dataset)
+}
+
+func (c *EvalController) AddCases(ctx *gin.Context) {
+	var req struct {
+		Cases []evalCaseRequest `json:"cases" binding:"required,min=1,dive"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !c.authorizeDataset(ctx, ctx.Param("id")) {
+		return
+	}
+
+	cases := make([]models.EvalCase, len(req.Cases))
+	for i, cr := range req.Cases {
+		cases[i] = cr.toModel()
+	}
+
+	created, err := c.svc.AddCases(ctx, ctx.Param("id"), cases)
+	if errors.Is(err, service.ErrInvalidEval) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// StartRun queues a run and returns immediately; the run executes in the
+// background and GetRun reports its progress.
+func (c *EvalController) StartRun(ctx *gin.Context) {
+	var req struct {
+		DatasetID       uuid.UUID             `json:"dataset_id" binding:"required"`
+		PromptVersionID uuid.UUID             `json:"prompt_version_id" binding:"required"`
+		Provider        string                `json:"provider"`
+		ModelName       string                `json:"model_name"`
+		Scorers         []models.ScorerConfig `json:"scorers" binding:"required,min=1"`
+		Concurrency     int                   `json:"concurrency"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !c.authorizeDataset(ctx, req.DatasetID.String()) {
+		return
+	}
+
+	run, err := c.svc.StartRun(ctx, &models.EvalRun{
+		DatasetID:       req.DatasetID,
+		PromptVersionID: req.PromptVersionID,
+		Provider:        req.Provider,
+		ModelName:       req.ModelName,
+		Scorers:         req.Scorers,
+		Concurrency:     req.Concurrency,
+	})
+	if errors.Is(err, service.ErrInvalidEval) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, run)
+}
+
+func (c *EvalController) ListRuns(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	runs, err := c.svc.ListRuns(ctx, module, ctx.Query("dataset_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, runs)
+}
+
+func (c *EvalController) GetRun(ctx *gin.Context) {
+	detail, err := c.svc.GetRun(ctx, ctx.Param("id"))
+	if err == nil && !authorizeModule(ctx, "Evaluation run not found", func() (string, error) {
+		return detail.Run.ModuleName, nil
+	}) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Evaluation run not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, detail)
+}
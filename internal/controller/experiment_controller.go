@@ -0,0 +1,143 @@
+// controller/experiment_controller.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ExperimentController struct {
+	svc *service.ExperimentService
+}
+
+func NewExperimentController(svc *service.ExperimentService) *ExperimentController {
+	return &ExperimentController{svc}
+}
+
+func (c *ExperimentController) Create(ctx *gin.Context) {
+	var req struct {
+		ModuleName string `json:"module_name" binding:"required"`
+		Name       string `json:"name" binding:"required"`
+		// SystemPromptID defaults to the prompt of the variants' versions.
+		SystemPromptID uuid.UUID `json:"system_prompt_id"`
+		Variants       []struct {
+			Name            string    `json:"name" binding:"required"`
+			Weight          int       `json:"weight" binding:"required"`
+			PromptVersionID uuid.UUID `json:"prompt_version_id" binding:"required"`
+			Provider        string    `json:"provider" binding:"required"`
+			ModelName       string    `json:"model_name" binding:"required"`
+		} `json:"variants" binding:"required,min=1,dive"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := moduleFor(ctx, req.ModuleName); !ok {
+		return
+	}
+
+	experiment := &models.Experiment{ModuleName: req.ModuleName, Name: req.Name, SystemPromptID: req.SystemPromptID}
+	for _, v := range req.Variants {
+		experiment.Variants = append(experiment.Variants, models.ExperimentVariant{
+			Name:            v.Name,
+			Weight:          v.Weight,
+			PromptVersionID: v.PromptVersionID,
+			Provider:        v.Provider,
+			ModelName:       v.ModelName,
+		})
+	}
+
+	created, err := c.svc.Create(ctx, experiment)
+	if errors.Is(err, service.ErrInvalidExperiment) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (c *ExperimentController) List(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	experiments, err := c.svc.List(ctx, module)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, experiments)
+}
+
+// authorizeExperiment checks the experiment in the :id parameter against
+// the caller's module.
+func (c *ExperimentController) authorizeExperiment(ctx *gin.Context) bool {
+	return authorizeModule(ctx, "Experiment not found", func() (string, error) {
+		e, err := c.svc.Get(ctx, ctx.Param("id"))
+		return e.ModuleName, err
+	})
+}
+
+func (c *ExperimentController) Get(ctx *gin.Context) {
+	experiment, err := c.svc.Get(ctx, ctx.Param("id"))
+	if err == nil && !c.authorizeExperiment(ctx) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Experiment not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, experiment)
+}
+
+func (c *ExperimentController) Start(ctx *gin.Context) {
+	if !c.authorizeExperiment(ctx) {
+		return
+	}
+	err := c.svc.Start(ctx, ctx.Param("id"))
+	if errors.Is(err, service.ErrInvalidExperiment) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+func (c *ExperimentController) Stop(ctx *gin.Context) {
+	if !c.authorizeExperiment(ctx) {
+		return
+	}
+	err := c.svc.Stop(ctx, ctx.Param("id"))
+	if errors.Is(err, service.ErrInvalidExperiment) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+func (c *ExperimentController) Results(ctx *gin.Context) {
+	if !c.authorizeExperiment(ctx) {
+		return
+	}
+	results, err := c.svc.Results(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Export returns the prompts and rate limits as a bundle, in YAML unless
+// ?format=json is given.
+func (c *SystemPromptController) Export(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	bundle, err := c.svc.ExportBundle(ctx, module)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	format := ctx.DefaultQuery("format", service.BundleFormatYAML)
+	body, err := bundle.Encode(format)
+	if errors.Is(err, service.ErrInvalidBundle) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	contentType := "application/yaml"
+	if format == service.BundleFormatJSON {
+		contentType = "application/json"
+	}
+	ctx.Data(http.StatusOK, contentType, body)
+}
+
+// Import applies a YAML or JSON bundle from the request body. ?mode=replace
+// also deletes what the bundle lacks; ?dry_run=true only reports the
+// changes.
+func (c *SystemPromptController) Import(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	raw, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bundle, err := service.ParseBundle(raw)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := c.svc.ImportBundle(ctx, bundle, service.ImportOptions{
+		Module: module,
+		Mode:   ctx.DefaultQuery("mode", service.ImportUpsert),
+		DryRun: ctx.Query("dry_run") == "true",
+		Author: actorName(ctx),
+	})
+	if errors.Is(err, service.ErrInvalidBundle) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		promptError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
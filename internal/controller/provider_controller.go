@@ -0,0 +1,104 @@
+// controller/provider_controller.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProviderController struct {
+	svc     *service.ProviderService
+	prompts *service.SystemPromptService
+}
+
+func NewProviderController(svc *service.ProviderService, prompts *service.SystemPromptService) *ProviderController {
+	return &ProviderController{svc: svc, prompts: prompts}
+}
+
+func (c *ProviderController) List(ctx *gin.Context) {
+	providers, err := c.svc.List(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, providers)
+}
+
+func (c *ProviderController) Get(ctx *gin.Context) {
+	p, err := c.svc.Get(ctx, ctx.Param("name"))
+	if err != nil {
+		providerError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, p)
+}
+
+func (c *ProviderController) SaveProvider(ctx *gin.Context) {
+	var req service.ProviderFields
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p, err := c.svc.SaveProvider(ctx, ctx.Param("name"), req)
+	if err != nil {
+		providerError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, p)
+}
+
+func (c *ProviderController) DeleteProvider(ctx *gin.Context) {
+	if err := c.svc.DeleteProvider(ctx, ctx.Param("name")); err != nil {
+		providerError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *ProviderController) SaveModel(ctx *gin.Context) {
+	var req service.ModelFields
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	m, err := c.svc.SaveModel(ctx, ctx.Param("name"), ctx.Param("model"), req)
+	if err != nil {
+		providerError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, m)
+}
+
+func (c *ProviderController) DeleteModel(ctx *gin.Context) {
+	if err := c.svc.DeleteModel(ctx, ctx.Param("name"), ctx.Param("model")); err != nil {
+		providerError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// TestModel sends a canned prompt to a model and reports each step. A
+// failing model is still a 200; the problems are in the result.
+func (c *ProviderController) TestModel(ctx *gin.Context) {
+	res, err := c.prompts.TestModel(ctx, ctx.Param("name"), ctx.Param("model"))
+	if err != nil {
+		providerError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func providerError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidProvider):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Provider or model not found"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
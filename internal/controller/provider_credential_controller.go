@@ -0,0 +1,92 @@
+// controller/provider_credential_controller.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abeselom-personal/go-ai-service/internal/middleware"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProviderCredentialController struct {
+	svc *service.ProviderCredentialService
+}
+
+func NewProviderCredentialController(svc *service.ProviderCredentialService) *ProviderCredentialController {
+	return &ProviderCredentialController{svc}
+}
+
+func (c *ProviderCredentialController) Set(ctx *gin.Context) {
+	var req struct {
+		// ModuleName is empty for a key used by every module of the tenant.
+		ModuleName string `json:"module_name"`
+		Provider   string `json:"provider" binding:"required"`
+		APIKey     string `json:"api_key" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	module, ok := moduleFor(ctx, req.ModuleName)
+	if !ok {
+		return
+	}
+	cred, err := c.svc.Set(ctx, module, req.Provider, req.APIKey)
+	if errors.Is(err, service.ErrInvalidCredential) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, cred)
+}
+
+func (c *ProviderCredentialController) List(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	creds, err := c.svc.List(ctx, module)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, creds)
+}
+
+func (c *ProviderCredentialController) Delete(ctx *gin.Context) {
+	cred, err := c.svc.Get(ctx, ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if p := middleware.PrincipalFrom(ctx); p != nil && p.Module != "" && p.Module != cred.ModuleName {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "credential belongs to another module"})
+		return
+	}
+	if err := c.svc.Delete(ctx, cred.ID.String()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Reencrypt reseals credentials of every tenant. Its route is limited to
+// the platform key.
+func (c *ProviderCredentialController) Reencrypt(ctx *gin.Context) {
+	n, err := c.svc.Reencrypt(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "reencrypted": n})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"reencrypted": n})
+}
@@ -0,0 +1,91 @@
+// controller/replay_controller.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReplayController struct {
+	svc *service.ReplayService
+}
+
+func NewReplayController(svc *service.ReplayService) *ReplayController {
+	return &ReplayController{svc}
+}
+
+// Create queues a replay job; it runs in the background and Report shows
+// its results so far.
+func (c *ReplayController) Create(ctx *gin.Context) {
+	var req struct {
+		ModuleName      string     `json:"module_name" binding:"required"`
+		From            *time.Time `json:"from"`
+		To              *time.Time `json:"to"`
+		SampleSize      int        `json:"sample_size"`
+		PromptVersionID *uuid.UUID `json:"prompt_version_id"`
+		Provider        string     `json:"provider"`
+		ModelName       string     `json:"model_name"`
+		Concurrency     int        `json:"concurrency"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := moduleFor(ctx, req.ModuleName); !ok {
+		return
+	}
+
+	job, err := c.svc.StartJob(ctx, &models.ReplayJob{
+		ModuleName:      req.ModuleName,
+		From:            req.From,
+		To:              req.To,
+		SampleSize:      req.SampleSize,
+		PromptVersionID: req.PromptVersionID,
+		Provider:        req.Provider,
+		ModelName:       req.ModelName,
+		Concurrency:     req.Concurrency,
+	})
+	if errors.Is(err, service.ErrInvalidReplay) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+func (c *ReplayController) List(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	jobs, err := c.svc.ListJobs(ctx, module)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+func (c *ReplayController) Report(ctx *gin.Context) {
+	if !authorizeModule(ctx, "Replay job not found", func() (string, error) {
+		job, err := c.svc.GetJob(ctx, ctx.Param("id"))
+		return job.ModuleName, err
+	}) {
+		return
+	}
+	report, err := c.svc.Report(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Replay job not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
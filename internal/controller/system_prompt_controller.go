@@ -2,12 +2,19 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/abeselom-personal/go-ai-service/internal/middleware"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
 	"github.com/abeselom-personal/go-ai-service/internal/service"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type SystemPromptController struct {
@@ -20,89 +27,459 @@ func NewSystemPromptController(svc *service.SystemPromptService) *SystemPromptCo
 
 func (c *SystemPromptController) Create(ctx *gin.Context) {
 	var req struct {
-		ModuleName   string `json:"module_name" binding:"required"`
-		ModelName    string `json:"model_name" binding:"required"`
-		Provider     string `json:"provider" binding:"required"`
-		SystemPrompt string `json:"system_prompt" binding:"required"`
+		ModuleName   string                 `json:"module_name" binding:"required"`
+		Name         string                 `json:"name"`
+		ModelName    string                 `json:"model_name" binding:"required"`
+		Provider     string                 `json:"provider" binding:"required"`
+		SystemPrompt string                 `json:"system_prompt" binding:"required"`
+		Variables    models.PromptVariables `json:"variables"`
+		ChangeNote   string                 `json:"change_note"`
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	prompt, err := c.svc.Create(ctx, req.ModuleName, req.Provider, req.SystemPrompt, req.ModelName)
+	if _, ok := moduleFor(ctx, req.ModuleName); !ok {
+		return
+	}
+	prompt, err := c.svc.Create(ctx, req.ModuleName, service.PromptFields{
+		Name:         req.Name,
+		Provider:     req.Provider,
+		ModelName:    req.ModelName,
+		SystemPrompt: req.SystemPrompt,
+		Variables:    req.Variables,
+	}, actorName(ctx), req.ChangeNote)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		promptError(ctx, err)
 		return
 	}
+	ctx.Header("ETag", etag(prompt))
 	ctx.JSON(http.StatusCreated, prompt)
 }
 
+// promptError responds to a failed prompt write.
+func promptError(ctx *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidPrompt):
+		status = http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrPromptNameTaken), errors.Is(err, service.ErrPromptManaged):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrVersionConflict):
+		status = http.StatusPreconditionFailed
+	}
+	ctx.JSON(status, gin.H{"error": err.Error()})
+}
+
+// etag identifies the version of a prompt for If-Match.
+func etag(sp *models.SystemPrompt) string {
+	return strconv.Quote(strconv.Itoa(sp.Version))
+}
+
+// ifMatch returns the prompt version the If-Match header requires, or nil
+// when any version will do.
+func ifMatch(ctx *gin.Context) (*int, bool) {
+	raw := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if raw == "" || raw == "*" {
+		return nil, true
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(raw, "W/"), `"`))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must be an ETag returned by this API"})
+		return nil, false
+	}
+	return &version, true
+}
+
+// authorizePrompt responds with 404 or 403 and returns false unless the
+// prompt in the :id parameter exists and the caller may act on its module.
+func (c *SystemPromptController) authorizePrompt(ctx *gin.Context) bool {
+	return c.authorizePromptIn(ctx, c.svc.GetByID)
+}
+
+// authorizeDeletedPrompt is authorizePrompt for prompts that may be in the
+// trash.
+func (c *SystemPromptController) authorizeDeletedPrompt(ctx *gin.Context) bool {
+	return c.authorizePromptIn(ctx, c.svc.GetWithDeleted)
+}
+
+func (c *SystemPromptController) authorizePromptIn(ctx *gin.Context,
+	get func(context.Context, string) (*models.SystemPrompt, error)) bool {
+	p := middleware.PrincipalFrom(ctx)
+	if p == nil || p.Module == "" {
+		return true
+	}
+	sp, err := get(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return false
+	}
+	_, ok := moduleFor(ctx, sp.ModuleName)
+	return ok
+}
+
+// Get returns a page of prompts, or of the ones in the trash with
+// ?deleted=true. They can be filtered by module_name, provider and
+// model_name, searched with q and sorted with sort (created_at or
+// updated_at) and order (asc or desc). Pass the next_cursor of a page as
+// cursor to get the next one.
 func (c *SystemPromptController) Get(ctx *gin.Context) {
-	prompt, err := c.svc.Get(ctx)
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	q := repository.PromptQuery{
+		Module:    module,
+		Name:      ctx.Query("name"),
+		Provider:  ctx.Query("provider"),
+		Model:     ctx.Query("model_name"),
+		Search:    ctx.Query("q"),
+		Deleted:   ctx.Query("deleted") == "true",
+		SortBy:    ctx.Query("sort"),
+		Ascending: ctx.Query("order") == "asc",
+	}
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		q.Limit = limit
+	}
+
+	page, err := c.svc.List(ctx, q, ctx.Query("cursor"))
+	if errors.Is(err, service.ErrInvalidListQuery) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, page)
+}
+
+// GetByID returns a prompt with its version as ETag.
+func (c *SystemPromptController) GetByID(ctx *gin.Context) {
+	if !c.authorizePrompt(ctx) {
+		return
+	}
+	prompt, err := c.svc.GetByID(ctx, ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
 		return
 	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("ETag", etag(prompt))
 	ctx.JSON(http.StatusOK, prompt)
 }
 
+// Update edits a prompt. Omitted fields keep their values. With If-Match
+// set to the prompt's ETag the update fails with 412 if someone else
+// changed the prompt in the meantime.
 func (c *SystemPromptController) Update(ctx *gin.Context) {
-	id := ctx.Param("id")
+	if !c.authorizePrompt(ctx) {
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name         string                 `json:"name"`
+		Provider     string                 `json:"provider"`
+		ModelName    string                 `json:"model_name"`
+		SystemPrompt string                 `json:"system_prompt"`
+		Variables    models.PromptVariables `json:"variables"`
+		ChangeNote   string                 `json:"change_note"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prompt, err := c.svc.Update(ctx, ctx.Param("id"), service.PromptFields{
+		Name:         req.Name,
+		Provider:     req.Provider,
+		ModelName:    req.ModelName,
+		SystemPrompt: req.SystemPrompt,
+		Variables:    req.Variables,
+	}, version, actorName(ctx), req.ChangeNote)
+	if err != nil {
+		promptError(ctx, err)
+		return
+	}
+	ctx.Header("ETag", etag(prompt))
+	ctx.JSON(http.StatusOK, prompt)
+}
+
+func (c *SystemPromptController) ListVersions(ctx *gin.Context) {
+	if !c.authorizePrompt(ctx) {
+		return
+	}
+	versions, err := c.svc.ListVersions(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, versions)
+}
+
+func (c *SystemPromptController) DiffVersions(ctx *gin.Context) {
+	if !c.authorizePrompt(ctx) {
+		return
+	}
+	from, err := strconv.Atoi(ctx.Query("from"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "from must be a version number"})
+		return
+	}
+	to, err := strconv.Atoi(ctx.Query("to"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must be a version number"})
+		return
+	}
+	diff, err := c.svc.DiffVersions(ctx, ctx.Param("id"), from, to)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, diff)
+}
+
+func (c *SystemPromptController) Rollback(ctx *gin.Context) {
+	if !c.authorizePrompt(ctx) {
+		return
+	}
+	var req struct {
+		Version    int    `json:"version" binding:"required,min=1"`
+		ChangeNote string `json:"change_note"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prompt, err := c.svc.Rollback(ctx, ctx.Param("id"), req.Version, actorName(ctx), req.ChangeNote)
+	if err != nil {
+		promptError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, prompt)
+}
+
+func (c *SystemPromptController) ListLabels(ctx *gin.Context) {
+	if !c.authorizePrompt(ctx) {
+		return
+	}
+	labels, err := c.svc.ListLabels(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, labels)
+}
+
+func (c *SystemPromptController) SetLabel(ctx *gin.Context) {
+	if !c.authorizePrompt(ctx) {
+		return
+	}
 	var req struct {
-		SystemPrompt string `json:"system_prompt" binding:"required"`
-		UserPrompt   string `json:"user_prompt" binding:"required"`
+		Version int `json:"version" binding:"required,min=1"`
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := c.svc.Update(ctx, id, req.SystemPrompt, req.UserPrompt); err != nil {
+	label, err := c.svc.SetLabel(ctx, ctx.Param("id"), ctx.Param("label"), req.Version, actorName(ctx))
+	if errors.Is(err, service.ErrInvalidPrompt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	ctx.Status(http.StatusOK)
+	ctx.JSON(http.StatusOK, label)
 }
 
+func (c *SystemPromptController) Promote(ctx *gin.Context) {
+	if !c.authorizePrompt(ctx) {
+		return
+	}
+	var req struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.From == "" {
+		req.From = models.LabelStaging
+	}
+	if req.To == "" {
+		req.To = models.LabelProduction
+	}
+	label, err := c.svc.Promote(ctx, ctx.Param("id"), req.From, req.To, actorName(ctx))
+	if errors.Is(err, service.ErrInvalidPrompt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, label)
+}
+
+// Delete moves a prompt to the trash, or with ?purge=true, which needs
+// the admin scope, deletes it for good.
 func (c *SystemPromptController) Delete(ctx *gin.Context) {
-	id := ctx.Param("id")
-	if err := c.svc.Delete(ctx, id); err != nil {
+	purge := ctx.Query("purge") == "true"
+	if purge {
+		if p := middleware.PrincipalFrom(ctx); p != nil && !p.HasScope(models.ScopeAdmin) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "missing scope " + models.ScopeAdmin})
+			return
+		}
+		if !c.authorizeDeletedPrompt(ctx) {
+			return
+		}
+	} else if !c.authorizePrompt(ctx) {
+		return
+	}
+
+	remove := c.svc.Delete
+	if purge {
+		remove = c.svc.Purge
+	}
+	err := remove(ctx, ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return
+	}
+	if errors.Is(err, service.ErrPromptManaged) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	ctx.Status(http.StatusNoContent)
 }
 
+// Restore takes a prompt out of the trash.
+func (c *SystemPromptController) Restore(ctx *gin.Context) {
+	if !c.authorizeDeletedPrompt(ctx) {
+		return
+	}
+	prompt, err := c.svc.Restore(ctx, ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidPrompt) || errors.Is(err, service.ErrPromptNameTaken) ||
+		errors.Is(err, service.ErrPromptManaged) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, prompt)
+}
+
 func (c *SystemPromptController) Send(ctx *gin.Context) {
 	var req struct {
-		ModuleName   string `json:"module_name" binding:"required"`
-		SystemPrompt string `json:"system_prompt" binding:"required"`
-		UserPrompt   string `json:"user_prompt" binding:"required"`
+		// ModuleName is taken from the API key when it is bound to a module.
+		ModuleName   string                 `json:"module_name"`
+		SystemPrompt string                 `json:"system_prompt" binding:"required_without_all=PromptID Label"`
+		PromptID     string                 `json:"prompt_id"`
+		Label        string                 `json:"label"`
+		SubjectID    string                 `json:"subject_id"`
+		Variables    map[string]interface{} `json:"variables"`
+		UserPrompt   string                 `json:"user_prompt" binding:"required"`
+		Retrieval    *struct {
+			Collection string `json:"collection" binding:"required"`
+			TopK       int    `json:"top_k"`
+		} `json:"retrieval"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	module, ok := moduleFor(ctx, req.ModuleName)
+	if !ok {
+		return
+	}
+	if module == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "module_name is required"})
+		return
+	}
 
 	// Get cache control parameter
 	bypassCache, _ := strconv.ParseBool(ctx.Query("cache"))
 
-	response, err := c.svc.SendPrompt(
-		ctx,
-		req.ModuleName,
-		req.SystemPrompt,
-		req.UserPrompt,
-		bypassCache,
-	)
+	sendReq := service.SendRequest{
+		Module:       module,
+		SystemPrompt: req.SystemPrompt,
+		UserPrompt:   req.UserPrompt,
+		BypassCache:  bypassCache,
+		PromptID:     req.PromptID,
+		Label:        req.Label,
+		Variables:    req.Variables,
+		SubjectID:    req.SubjectID,
+	}
+	if req.Retrieval != nil {
+		sendReq.Retrieval = &service.RetrievalOptions{
+			Collection: req.Retrieval.Collection,
+			TopK:       req.Retrieval.TopK,
+		}
+	}
+
+	response, err := c.svc.SendPrompt(ctx, sendReq)
 
+	var varErr *service.PromptVariableError
+	if errors.As(err, &varErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": varErr.Error(), "variables": varErr})
+		return
+	}
+	var guardErr *service.GuardrailError
+	if errors.As(err, &guardErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": guardErr.Error(),
+			"code":  service.GuardrailErrorCode,
+			"stage": guardErr.Stage,
+			"rule":  guardErr.Rule,
+		})
+		return
+	}
+	var piiErr *service.PIIBlockedError
+	if errors.As(err, &piiErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": piiErr.Error(), "entities": piiErr.Entities})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidPrompt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"response":  response.Response,
-		"cached":    !bypassCache && time.Since(response.UsedAt) > time.Second,
-		"timestamp": response.UsedAt,
+		"id":         response.ID,
+		"response":   response.Response,
+		"cached":     !bypassCache && time.Since(response.UsedAt) > time.Second,
+		"timestamp":  response.UsedAt,
+		"redactions": response.Redactions,
+		"guardrails": response.GuardrailHits,
 	})
 }
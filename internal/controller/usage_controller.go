@@ -0,0 +1,103 @@
+// controller/usage_controller.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UsageController struct {
+	svc *service.UsageService
+}
+
+func NewUsageController(svc *service.UsageService) *UsageController {
+	return &UsageController{svc}
+}
+
+// authorizeLog checks the usage log in the :id parameter against the
+// caller's module.
+func (c *UsageController) authorizeLog(ctx *gin.Context) bool {
+	return authorizeModule(ctx, "Usage log not found", func() (string, error) {
+		entry, err := c.svc.GetLog(ctx, ctx.Param("id"))
+		return entry.ModuleName, err
+	})
+}
+
+func (c *UsageController) AddFeedback(ctx *gin.Context) {
+	if !c.authorizeLog(ctx) {
+		return
+	}
+	var req struct {
+		Thumbs  string   `json:"thumbs"`
+		Score   *float64 `json:"score"`
+		Comment string   `json:"comment"`
+		Tags    []string `json:"tags"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fb, err := c.svc.AddFeedback(ctx, ctx.Param("id"), service.FeedbackInput{
+		Thumbs:  req.Thumbs,
+		Score:   req.Score,
+		Comment: req.Comment,
+		Tags:    req.Tags,
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidFeedback):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Usage log not found"})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, fb)
+}
+
+func (c *UsageController) ListFeedback(ctx *gin.Context) {
+	if !c.authorizeLog(ctx) {
+		return
+	}
+	feedback, err := c.svc.ListFeedback(ctx, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, feedback)
+}
+
+func (c *UsageController) Report(ctx *gin.Context) {
+	module, ok := moduleFor(ctx, ctx.Query("module_name"))
+	if !ok {
+		return
+	}
+	filter := repository.UsageFilter{Module: module}
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := ctx.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC3339 timestamp"})
+			return
+		}
+		*dst = &t
+	}
+
+	report, err := c.svc.Report(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -42,5 +43,13 @@ func NewPostgresDB(cfg Config) (*gorm.DB, error) {
 	// Enable UUID extension
 	db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`)
 
+	// Enable pgvector for document chunk embeddings
+	db.Exec(`CREATE EXTENSION IF NOT EXISTS vector`)
+
+	// Stamp the request's tenant on every new row
+	if err := tenant.Register(db); err != nil {
+		return nil, fmt.Errorf("failed to register tenant callback: %w", err)
+	}
+
 	return db, nil
 }
@@ -0,0 +1,123 @@
+// Package encryption seals secrets at rest with AES-256-GCM under the
+// configured encryption key. Sealed values name the key version they were
+// sealed with, so the key can be rotated while older values stay readable
+// until they are re-encrypted.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+)
+
+var (
+	// ErrUnknownKeyVersion is returned when a value was sealed with a key
+	// that is no longer configured.
+	ErrUnknownKeyVersion = errors.New("unknown encryption key version")
+	// ErrMalformed is returned for values that are not sealed values or
+	// fail authentication.
+	ErrMalformed = errors.New("malformed sealed value")
+)
+
+// Keyring holds the current key and any previous keys still needed to
+// open values sealed before a rotation.
+type Keyring struct {
+	current int
+	aeads   map[int]cipher.AEAD
+}
+
+// NewKeyring builds a keyring from the security config. Keys are 32 bytes
+// for AES-256; a zero version means 1.
+func NewKeyring(sec config.SecurityConfig) (*Keyring, error) {
+	k := &Keyring{current: sec.EncryptionKeyVersion, aeads: map[int]cipher.AEAD{}}
+	if k.current == 0 {
+		k.current = 1
+	}
+	if err := k.add(k.current, sec.EncryptionKey); err != nil {
+		return nil, err
+	}
+	for _, prev := range sec.PreviousKeys {
+		if prev.Version == k.current {
+			return nil, fmt.Errorf("previous key reuses current version %d", prev.Version)
+		}
+		if err := k.add(prev.Version, prev.Key); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+func (k *Keyring) add(version int, key string) error {
+	if len(key) != 32 {
+		return fmt.Errorf("encryption key version %d must be 32 bytes", version)
+	}
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	k.aeads[version] = aead
+	return nil
+}
+
+// Version is the version new values are sealed with.
+func (k *Keyring) Version() int {
+	return k.current
+}
+
+// Seal encrypts plaintext under the current key. aad is authenticated but
+// not stored; Open must be given the same aad, which binds the value to
+// the record it belongs to. The result has the form "v<version>:<base64>".
+func (k *Keyring) Seal(plaintext, aad []byte) (string, error) {
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, aad)
+	return "v" + strconv.Itoa(k.current) + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal with any configured key version.
+func (k *Keyring) Open(value string, aad []byte) ([]byte, error) {
+	version, err := VersionOf(value)
+	if err != nil {
+		return nil, err
+	}
+	aead, ok := k.aeads[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKeyVersion, version)
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(value[strings.IndexByte(value, ':')+1:])
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
+
+// VersionOf returns the key version a sealed value was sealed with.
+func VersionOf(value string) (int, error) {
+	prefix, _, ok := strings.Cut(value, ":")
+	if !ok || !strings.HasPrefix(prefix, "v") {
+		return 0, ErrMalformed
+	}
+	version, err := strconv.Atoi(prefix[1:])
+	if err != nil {
+		return 0, ErrMalformed
+	}
+	return version, nil
+}
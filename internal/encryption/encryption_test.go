@@ -0,0 +1,58 @@
+package encryption_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	keyV1 = "0123456789abcdef0123456789abcdef"
+	keyV2 = "fedcba9876543210fedcba9876543210"
+)
+
+func TestKeyring_SealOpenAndRotate(t *testing.T) {
+	v1, err := encryption.NewKeyring(config.SecurityConfig{EncryptionKey: keyV1, EncryptionKeyVersion: 1})
+	require.NoError(t, err)
+
+	sealed, err := v1.Seal([]byte("sk-secret"), []byte("row-1"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, "v1:"))
+	assert.NotContains(t, sealed, "sk-secret")
+
+	plain, err := v1.Open(sealed, []byte("row-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", string(plain))
+
+	_, err = v1.Open(sealed, []byte("row-2"))
+	assert.ErrorIs(t, err, encryption.ErrMalformed, "aad binds the value to its row")
+	_, err = v1.Open("v1:"+strings.Repeat("A", 40), []byte("row-1"))
+	assert.ErrorIs(t, err, encryption.ErrMalformed)
+
+	v2only, err := encryption.NewKeyring(config.SecurityConfig{EncryptionKey: keyV2, EncryptionKeyVersion: 2})
+	require.NoError(t, err)
+	_, err = v2only.Open(sealed, []byte("row-1"))
+	assert.ErrorIs(t, err, encryption.ErrUnknownKeyVersion)
+
+	v2, err := encryption.NewKeyring(config.SecurityConfig{
+		EncryptionKey: keyV2, EncryptionKeyVersion: 2,
+		PreviousKeys: []config.PreviousKey{{Version: 1, Key: keyV1}},
+	})
+	require.NoError(t, err)
+	plain, err = v2.Open(sealed, []byte("row-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", string(plain))
+
+	resealed, err := v2.Seal(plain, []byte("row-1"))
+	require.NoError(t, err)
+	version, err := encryption.VersionOf(resealed)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = encryption.NewKeyring(config.SecurityConfig{EncryptionKey: "short"})
+	assert.Error(t, err)
+}
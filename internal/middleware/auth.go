@@ -0,0 +1,115 @@
+// Package middleware holds the Gin middleware shared by the API routes.
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+type Auth struct {
+	keys *service.APIKeyService
+	jwt  *service.JWTVerifier
+	cfg  *config.Config
+}
+
+// NewAuth authenticates API keys, and bearer JWTs as well when jwt is not
+// nil.
+func NewAuth(keys *service.APIKeyService, jwt *service.JWTVerifier, cfg *config.Config) *Auth {
+	return &Auth{keys: keys, jwt: jwt, cfg: cfg}
+}
+
+// Require authenticates the request, once per request, and rejects it
+// unless the caller holds scope. The caller's tenant is put on the request
+// context along with the caller for auditing; the router must be set to
+// fall back to it. Credentials are read from "Authorization: Bearer <key or
+// JWT>" or "X-API-Key". Everything passes when auth is disabled.
+func (a *Auth) Require(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !a.cfg.Auth.Enabled {
+			ctx.Next()
+			return
+		}
+
+		p := PrincipalFrom(ctx)
+		if p == nil {
+			var err error
+			if p, err = a.authenticate(ctx); err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, service.ErrUnauthenticated) {
+					status = http.StatusUnauthorized
+				}
+				ctx.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.Set(principalKey, p)
+			reqCtx := tenant.WithTenant(ctx.Request.Context(), tenantOf(ctx, p))
+			actor := audit.ActorFrom(reqCtx)
+			actor.ID, actor.Name = p.KeyID, p.Name
+			ctx.Request = ctx.Request.WithContext(audit.WithActor(reqCtx, actor))
+		}
+
+		if !p.HasScope(scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing scope " + scope})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireGlobal rejects callers bound to a tenant, leaving the platform
+// (bootstrap) key. It guards settings shared by every tenant and must run
+// after Require.
+func RequireGlobal() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if p := PrincipalFrom(ctx); p != nil && p.TenantID != "" {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "only the platform key may do this"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func (a *Auth) authenticate(ctx *gin.Context) (*service.Principal, error) {
+	token := presentedKey(ctx)
+	if a.jwt != nil && service.LooksLikeJWT(token) {
+		return a.jwt.Verify(ctx, token)
+	}
+	return a.keys.Authenticate(ctx, token)
+}
+
+// tenantOf is the principal's tenant. The bootstrap key has none and acts
+// in the tenant named by the X-Tenant-ID header, or the default one.
+func tenantOf(ctx *gin.Context, p *service.Principal) string {
+	if p.TenantID != "" {
+		return p.TenantID
+	}
+	if id := ctx.GetHeader("X-Tenant-ID"); id != "" {
+		return id
+	}
+	return tenant.Default
+}
+
+func presentedKey(ctx *gin.Context) string {
+	if h := ctx.GetHeader("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+	return ctx.GetHeader("X-API-Key")
+}
+
+// PrincipalFrom returns the caller authenticated by Require, or nil when
+// auth is disabled.
+func PrincipalFrom(ctx *gin.Context) *service.Principal {
+	if v, ok := ctx.Get(principalKey); ok {
+		return v.(*service.Principal)
+	}
+	return nil
+}
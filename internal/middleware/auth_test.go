@@ -0,0 +1,155 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/middleware"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_RequireEnforcesScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := &config.Config{Auth: config.AuthConfig{Enabled: true}}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	auth := middleware.NewAuth(keys, nil, cfg)
+
+	r := gin.New()
+	r.POST("/send", auth.Require(models.ScopeSend), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, middleware.PrincipalFrom(ctx).Module)
+	})
+
+	sender, err := keys.Issue(context.Background(), "sender", "support", []string{models.ScopeSend}, nil)
+	require.NoError(t, err)
+	reader, err := keys.Issue(context.Background(), "reader", "support", []string{models.ScopePromptsRead}, nil)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		header, value string
+		status        int
+	}{
+		"no key":        {"", "", http.StatusUnauthorized},
+		"unknown key":   {"X-API-Key", "aik_000000000000_nope", http.StatusUnauthorized},
+		"missing scope": {"X-API-Key", reader.Secret, http.StatusForbidden},
+		"bearer":        {"Authorization", "Bearer " + sender.Secret, http.StatusOK},
+		"header":        {"X-API-Key", sender.Secret, http.StatusOK},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/send", nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusOK {
+				assert.Equal(t, "support", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuth_RequirePropagatesTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := &config.Config{Auth: config.AuthConfig{Enabled: true, BootstrapKey: "root-secret"}}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	auth := middleware.NewAuth(keys, nil, cfg)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.GET("/whoami", auth.Require(models.ScopePromptsRead), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, tenant.FromContext(ctx))
+	})
+
+	issued, err := keys.Issue(tenant.WithTenant(context.Background(), "acme"), "reader", "",
+		[]string{models.ScopePromptsRead}, nil)
+	require.NoError(t, err)
+
+	whoami := func(key, tenantHeader string) string {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("X-API-Key", key)
+		if tenantHeader != "" {
+			req.Header.Set("X-Tenant-ID", tenantHeader)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "acme", whoami(issued.Secret, ""))
+	assert.Equal(t, "acme", whoami(issued.Secret, "globex"), "tenant keys cannot switch tenant")
+	assert.Equal(t, tenant.Default, whoami("root-secret", ""))
+	assert.Equal(t, "globex", whoami("root-secret", "globex"))
+}
+
+func TestRequestInfo_RecordsActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := &config.Config{Auth: config.AuthConfig{Enabled: true, BootstrapKey: "root-secret"}}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	auth := middleware.NewAuth(keys, nil, cfg)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(middleware.RequestInfo())
+	r.GET("/whoami", auth.Require(models.ScopePromptsRead), func(ctx *gin.Context) {
+		a := audit.ActorFrom(ctx)
+		ctx.String(http.StatusOK, a.Name+"|"+a.IP+"|"+a.RequestID)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-API-Key", "root-secret")
+	req.Header.Set(middleware.RequestIDHeader, "trace-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "bootstrap|192.0.2.1|trace-42", w.Body.String())
+	assert.Equal(t, "trace-42", w.Header().Get(middleware.RequestIDHeader))
+
+	req.Header.Del(middleware.RequestIDHeader)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.NotEmpty(t, w.Header().Get(middleware.RequestIDHeader), "a request ID is generated when none is sent")
+}
+
+func TestAuth_RequireGlobalRejectsTenantKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := &config.Config{Auth: config.AuthConfig{Enabled: true, BootstrapKey: "root-secret"}}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	auth := middleware.NewAuth(keys, nil, cfg)
+
+	r := gin.New()
+	r.PUT("/providers", auth.Require(models.ScopeAdmin), middleware.RequireGlobal(), func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	tenantAdmin, err := keys.Issue(tenant.WithTenant(context.Background(), "acme"), "admin", "",
+		[]string{models.ScopeAdmin}, nil)
+	require.NoError(t, err)
+
+	put := func(key string) int {
+		req := httptest.NewRequest(http.MethodPut, "/providers", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, put(tenantAdmin.Secret))
+	assert.Equal(t, http.StatusNoContent, put("root-secret"))
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID, taken from the caller when set
+// and echoed on the response.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestInfo puts the client IP and request ID on the request context so
+// changes made by the request can be audited. Require adds the caller once
+// authenticated.
+func RequestInfo() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		ctx.Header(RequestIDHeader, id)
+		ctx.Request = ctx.Request.WithContext(audit.WithActor(ctx.Request.Context(),
+			audit.Actor{IP: ctx.ClientIP(), RequestID: id}))
+		ctx.Next()
+	}
+}
@@ -4,14 +4,71 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	UsageKindCompletion = "completion"
+	UsageKindEmbedding  = "embedding"
+
+	UsageStatusOK    = "ok"
+	UsageStatusError = "error"
+	// UsageStatusBlocked marks requests or responses stopped by a guardrail.
+	UsageStatusBlocked = "blocked"
+
+	// Live traffic has an empty Source.
+	UsageSourceEval   = "eval"
+	UsageSourceReplay = "replay"
 )
 
 type AIUsageLog struct {
 	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID   string    `gorm:"index;not null;default:default"`
 	ModuleName string    `gorm:"index;not null"`
 	Provider   string    `gorm:"index;not null"`
-	PromptHash string    `gorm:"index;not null"`
-	Request    string    `gorm:"type:text;not null"`
-	Response   string    `gorm:"type:text;not null"`
-	UsedAt     time.Time `gorm:"autoCreateTime"`
+	Kind       string    `gorm:"index;not null;default:completion"` // "completion" or "embedding"
+	// PromptHash stays plaintext so cache lookups work when the bodies
+	// below are encrypted at rest (security.encrypt_usage_logs).
+	PromptHash string `gorm:"index;not null"`
+	Request    string `gorm:"type:text;not null;serializer:sealed"`
+	Response   string `gorm:"type:text;not null;serializer:sealed"`
+	// UserPrompt and Variables are the caller's inputs, kept apart from the
+	// combined Request so the call can be replayed against another prompt.
+	UserPrompt string  `gorm:"type:text;serializer:sealed"`
+	Variables  JSONMap `gorm:"type:text"`
+	// Redactions counts the PII entities removed from the request and
+	// response before they were sent and stored.
+	Redactions EntityCounts `gorm:"type:text"`
+	// GuardrailHits lists the guardrail rules that fired, as
+	// "<stage>:<rule>" with stage "input" or "output".
+	GuardrailHits StringList `gorm:"type:text"`
+	// Source tells eval and replay traffic apart from live requests.
+	Source string `gorm:"index"`
+	// PromptVersionID is set when the request used a stored prompt.
+	PromptVersionID *uuid.UUID `gorm:"type:uuid;index"`
+
+	ModelName        string
+	Status           string `gorm:"index;not null;default:ok"` // "ok", "error" or "blocked"
+	Error            string `gorm:"type:text"`
+	LatencyMs        int64
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	// Flagged is set by negative feedback and keeps the response out of the cache.
+	Flagged bool `gorm:"not null;default:false"`
+
+	// ExperimentID and VariantID are set for traffic assigned to an A/B test.
+	ExperimentID *uuid.UUID `gorm:"type:uuid;index"`
+	VariantID    *uuid.UUID `gorm:"type:uuid;index"`
+
+	UsedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// AfterFind opens the sealed bodies of a loaded log.
+func (l *AIUsageLog) AfterFind(*gorm.DB) error {
+	return openSealed(l.TenantID, map[string]*string{
+		"request":     &l.Request,
+		"response":    &l.Response,
+		"user_prompt": &l.UserPrompt,
+	})
 }
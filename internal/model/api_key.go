@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopePromptsRead  = "prompts:read"
+	ScopePromptsWrite = "prompts:write"
+	ScopeSend         = "send"
+	// ScopeAdmin grants every other scope as well.
+	ScopeAdmin = "admin"
+)
+
+// AllScopes lists the scopes a key may be issued.
+var AllScopes = []string{ScopePromptsRead, ScopePromptsWrite, ScopeSend, ScopeAdmin}
+
+// APIKey authenticates API callers. Only a SHA-256 hash of the secret is
+// stored; Prefix is the public part of the key used to look it up. An
+// empty ModuleName grants access to every module.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID   string     `gorm:"index;not null;default:default"`
+	Name       string     `gorm:"not null"`
+	ModuleName string     `gorm:"index"`
+	Prefix     string     `gorm:"uniqueIndex;not null"`
+	SecretHash string     `gorm:"not null" json:"-"`
+	Scopes     StringList `gorm:"type:text;not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	// Soft-deleted prompts can be restored or purged for good.
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+
+	AuditResourcePrompt      = "system_prompt"
+	AuditResourcePromptLabel = "prompt_label"
+	AuditResourceAPIKey      = "api_key"
+	AuditResourceCredential  = "provider_credential"
+	AuditResourceRateLimit   = "rate_limit"
+	AuditResourceConfig      = "config"
+	AuditResourceProvider    = "provider"
+	AuditResourceModel       = "model"
+)
+
+// AuditEvent records one administrative change: who made it, from where,
+// and the resource as it was before and after. Before is empty for
+// creates and After for deletes. ModuleName is the module of the resource,
+// empty for tenant-wide ones.
+type AuditEvent struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID     string    `gorm:"index;not null;default:default"`
+	ModuleName   string    `gorm:"index"`
+	ActorID      string    `gorm:"index"`
+	Actor        string    `gorm:"index"`
+	Action       string    `gorm:"index;not null"`
+	ResourceType string    `gorm:"index:idx_audit_resource;not null"`
+	ResourceID   string    `gorm:"index:idx_audit_resource"`
+	Before       JSONMap   `gorm:"type:text"`
+	After        JSONMap   `gorm:"type:text"`
+	IP           string
+	RequestID    string    `gorm:"index"`
+	CreatedAt    time.Time `gorm:"index"`
+}
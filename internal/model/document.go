@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DocumentCollection struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID    string    `gorm:"index;not null;default:default"`
+	ModuleName  string    `gorm:"index;not null"`
+	Name        string    `gorm:"index;not null"`
+	Description string    `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Document struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID     string    `gorm:"index;not null;default:default"`
+	CollectionID uuid.UUID `gorm:"type:uuid;index;not null"`
+	Title        string    `gorm:"not null"`
+	Content      string    `gorm:"type:text;not null"`
+	ChunkCount   int
+	CreatedAt    time.Time
+}
+
+// DocumentChunk holds one embedded slice of a document. Embedding is a
+// pgvector column sized by the retrieval.dimensions setting on Postgres;
+// other dialects store the same literal as text.
+type DocumentChunk struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID     string    `gorm:"index;not null;default:default"`
+	CollectionID uuid.UUID `gorm:"type:uuid;index;not null"`
+	DocumentID   uuid.UUID `gorm:"type:uuid;index;not null"`
+	Position     int       `gorm:"not null"`
+	Content      string    `gorm:"type:text;not null"`
+	Embedding    Vector    `gorm:"type:vector;not null"`
+	Score        float64   `gorm:"-"`
+	CreatedAt    time.Time
+}
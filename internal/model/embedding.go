@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Vector is stored as a "[1,2,3]" literal, which is both valid JSON and
+// the text format pgvector accepts.
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	return valueJSON([]float32(v), v == nil)
+}
+
+// EmbeddingDimensionsKey is the gorm setting that holds the length of
+// document chunk embeddings when migrating, e.g.
+// db.Set(EmbeddingDimensionsKey, 768).AutoMigrate(...).
+const EmbeddingDimensionsKey = "ai_service:embedding_dimensions"
+
+// GormDBDataType declares columns tagged type:vector as vector(n) on
+// Postgres, with n from EmbeddingDimensionsKey, and as text elsewhere.
+// Columns tagged with another type keep it.
+func (Vector) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if field.TagSettings["TYPE"] != "vector" {
+		return ""
+	}
+	if db.Dialector.Name() != "postgres" {
+		return "text"
+	}
+	if n, ok := db.Get(EmbeddingDimensionsKey); ok {
+		if dims, ok := n.(int); ok && dims > 0 {
+			return fmt.Sprintf("vector(%d)", dims)
+		}
+	}
+	return "vector"
+}
+
+func (v *Vector) Scan(src interface{}) error {
+	var out []float32
+	if err := scanJSON(src, &out, "vector"); err != nil {
+		return err
+	}
+	*v = out
+	return nil
+}
+
+type EmbeddingCache struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID    string    `gorm:"index;not null;default:default"`
+	ModuleName  string    `gorm:"index;not null"`
+	Provider    string    `gorm:"index;not null"`
+	ModelName   string    `gorm:"index;not null"`
+	ContentHash string    `gorm:"index;not null"`
+	Vector      Vector    `gorm:"type:text;not null"`
+	CreatedAt   time.Time
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EvalRunPending   = "pending"
+	EvalRunRunning   = "running"
+	EvalRunCompleted = "completed"
+	EvalRunFailed    = "failed"
+)
+
+const (
+	ScorerExactMatch       = "exact_match"
+	ScorerRegex            = "regex"
+	ScorerJSONSchema       = "json_schema"
+	ScorerContainsKeywords = "contains_keywords"
+	ScorerLLMJudge         = "llm_judge"
+)
+
+// EvalDataset is a named set of test cases for one module's prompts.
+type EvalDataset struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID    string    `gorm:"uniqueIndex:idx_eval_dataset;not null;default:default"`
+	ModuleName  string    `gorm:"uniqueIndex:idx_eval_dataset;not null"`
+	Name        string    `gorm:"uniqueIndex:idx_eval_dataset;not null"`
+	Description string
+	Cases       []EvalCase `gorm:"foreignKey:DatasetID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// EvalCase is one input with what a good answer should look like.
+// Expected feeds exact_match (and regex when the scorer has no pattern);
+// Keywords add to the contains_keywords scorer's list.
+type EvalCase struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID  string     `gorm:"index;not null;default:default"`
+	DatasetID uuid.UUID  `gorm:"type:uuid;index;not null"`
+	Input     string     `gorm:"type:text;not null"`
+	Variables JSONMap    `gorm:"type:text"`
+	Expected  string     `gorm:"type:text"`
+	Keywords  StringList `gorm:"type:text"`
+	CreatedAt time.Time
+}
+
+// ScorerConfig selects a scorer and its settings for a run.
+type ScorerConfig struct {
+	Type     string          `json:"type"`
+	Pattern  string          `json:"pattern,omitempty"`
+	Keywords []string        `json:"keywords,omitempty"`
+	Schema   json.RawMessage `json:"schema,omitempty"`
+	// Rubric, Provider and ModelName configure llm_judge; the judge uses
+	// the active model when no model is given.
+	Rubric    string `json:"rubric,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	ModelName string `json:"model_name,omitempty"`
+}
+
+// ScorerConfigs is stored as a JSON array.
+type ScorerConfigs []ScorerConfig
+
+func (c ScorerConfigs) Value() (driver.Value, error) {
+	return valueJSON([]ScorerConfig(c), c == nil)
+}
+
+func (c *ScorerConfigs) Scan(src interface{}) error {
+	var out []ScorerConfig
+	if err := scanJSON(src, &out, "scorer configs"); err != nil {
+		return err
+	}
+	*c = out
+	return nil
+}
+
+// ScoreMap maps a scorer type to a score between 0 and 1.
+type ScoreMap map[string]float64
+
+func (m ScoreMap) Value() (driver.Value, error) {
+	return valueJSON(map[string]float64(m), m == nil)
+}
+
+func (m *ScoreMap) Scan(src interface{}) error {
+	var out map[string]float64
+	if err := scanJSON(src, &out, "score map"); err != nil {
+		return err
+	}
+	*m = out
+	return nil
+}
+
+// EvalRun runs one prompt version over a dataset. Provider and ModelName
+// are empty when the run used the active model. Scores holds the mean of
+// each scorer across all cases.
+type EvalRun struct {
+	ID              uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID        string    `gorm:"index;not null;default:default"`
+	DatasetID       uuid.UUID `gorm:"type:uuid;index;not null"`
+	ModuleName      string    `gorm:"index;not null"`
+	PromptVersionID uuid.UUID `gorm:"type:uuid;not null"`
+	Provider        string
+	ModelName       string
+	Scorers         ScorerConfigs `gorm:"type:text;not null"`
+	Concurrency     int
+	Status          string `gorm:"index;not null;default:pending"`
+	Error           string `gorm:"type:text"`
+	CaseCount       int
+	FailedCases     int
+	Scores          ScoreMap `gorm:"type:text"`
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	CreatedAt       time.Time
+}
+
+// EvalResult is the output and scores of one case in a run. Error is set
+// when the provider call or a scorer failed; failed scorers score 0.
+type EvalResult struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID   string     `gorm:"index;not null;default:default"`
+	RunID      uuid.UUID  `gorm:"type:uuid;index;not null"`
+	CaseID     uuid.UUID  `gorm:"type:uuid;not null"`
+	UsageLogID *uuid.UUID `gorm:"type:uuid"`
+	Output     string     `gorm:"type:text"`
+	Error      string     `gorm:"type:text"`
+	LatencyMs  int64
+	Scores     ScoreMap `gorm:"type:text"`
+	CreatedAt  time.Time
+}
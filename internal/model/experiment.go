@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExperimentDraft   = "draft"
+	ExperimentRunning = "running"
+	ExperimentStopped = "stopped"
+)
+
+// Experiment splits the /send traffic for one stored prompt between
+// weighted variants. A module runs at most one experiment at a time; the
+// partial unique index enforces that even for concurrent starts.
+type Experiment struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID   string    `gorm:"index;uniqueIndex:idx_experiment_running,where:status = 'running';not null;default:default"`
+	ModuleName string    `gorm:"index;uniqueIndex:idx_experiment_running;not null"`
+	// SystemPromptID is the prompt whose requests the experiment takes
+	// over. Every variant uses one of its versions.
+	SystemPromptID uuid.UUID `gorm:"type:uuid;index"`
+	Name           string    `gorm:"not null"`
+	Status         string    `gorm:"index;not null;default:draft"`
+	Variants       []ExperimentVariant
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ExperimentVariant is one arm of an experiment: a prompt version sent to a
+// provider/model. Weight is relative to the other variants.
+type ExperimentVariant struct {
+	ID              uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID        string    `gorm:"index;not null;default:default"`
+	ExperimentID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	Name            string    `gorm:"not null"`
+	Weight          int       `gorm:"not null"`
+	PromptVersionID uuid.UUID `gorm:"type:uuid;not null"`
+	Provider        string    `gorm:"not null"`
+	ModelName       string    `gorm:"not null"`
+}
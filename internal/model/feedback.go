@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ThumbsUp   = "up"
+	ThumbsDown = "down"
+)
+
+// Feedback is a caller's judgement of one AI response.
+type Feedback struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID   string    `gorm:"index;not null;default:default"`
+	UsageLogID uuid.UUID `gorm:"type:uuid;index;not null"`
+	ModuleName string    `gorm:"index;not null"`
+	Thumbs     string    `gorm:"index"` // "up", "down" or empty
+	Score      *float64
+	Comment    string     `gorm:"type:text"`
+	Tags       StringList `gorm:"type:text"`
+	CreatedAt  time.Time
+}
@@ -0,0 +1,30 @@
+package models
+
+// All returns every model managed by AutoMigrate.
+func All() []interface{} {
+	return []interface{}{
+		&AIUsageLog{},
+		&RateLimit{},
+		&SystemPrompt{},
+		&SystemPromptVersion{},
+		&PromptLabel{},
+		&EmbeddingCache{},
+		&DocumentCollection{},
+		&Document{},
+		&DocumentChunk{},
+		&Experiment{},
+		&ExperimentVariant{},
+		&Feedback{},
+		&EvalDataset{},
+		&EvalCase{},
+		&EvalRun{},
+		&EvalResult{},
+		&ReplayJob{},
+		&ReplayResult{},
+		&APIKey{},
+		&ProviderCredential{},
+		&Provider{},
+		&Model{},
+		&AuditEvent{},
+	}
+}
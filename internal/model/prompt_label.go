@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LabelDraft      = "draft"
+	LabelStaging    = "staging"
+	LabelProduction = "production"
+)
+
+// PromptLabel is a named pointer, such as "production", to one version of a
+// system prompt. The draft label always follows the newest version.
+type PromptLabel struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID       string    `gorm:"index;not null;default:default"`
+	SystemPromptID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_prompt_label;not null"`
+	ModuleName     string    `gorm:"index;not null"`
+	Label          string    `gorm:"uniqueIndex:idx_prompt_label;not null"`
+	VersionID      uuid.UUID `gorm:"type:uuid;not null"`
+	Version        int       `gorm:"not null"`
+	UpdatedBy      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Provider is an AI provider registered through the API. It is shared by
+// all tenants and merged over the providers of the config file: a row
+// with the name of a configured provider replaces its settings. API keys
+// are never stored here; they come from <NAME>_API_KEY or a tenant's
+// ProviderCredential.
+type Provider struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name           string    `gorm:"uniqueIndex;not null"`
+	BaseURL        string    `gorm:"not null"`
+	AuthMethod     string    `gorm:"not null;default:''"`
+	EmbeddingModel string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Model is a model of a provider registered through the API. Its fields
+// mirror config.ModelConfig; Config is the request body template.
+type Model struct {
+	ID                   uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ProviderName         string    `gorm:"uniqueIndex:idx_model_name;not null"`
+	Name                 string    `gorm:"uniqueIndex:idx_model_name;not null"`
+	Parameters           string    `gorm:"type:text"`
+	Config               string    `gorm:"type:text;not null"`
+	ResponsePath         string    `gorm:"not null"`
+	PromptTokensPath     string
+	CompletionTokensPath string
+	InputCostPer1K       float64
+	OutputCostPer1K      float64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
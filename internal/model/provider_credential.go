@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProviderCredential is a tenant's own API key for a provider, used
+// instead of the key from the environment. An empty ModuleName applies to
+// every module of the tenant. The key is stored sealed with the
+// encryption key of KeyVersion; Hint keeps its last characters for display.
+type ProviderCredential struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID   string    `gorm:"uniqueIndex:idx_provider_credential;not null;default:default"`
+	ModuleName string    `gorm:"uniqueIndex:idx_provider_credential;not null"`
+	Provider   string    `gorm:"uniqueIndex:idx_provider_credential;not null"`
+	SealedKey  string    `gorm:"type:text;not null" json:"-"`
+	KeyVersion int       `gorm:"index;not null"`
+	Hint       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -6,6 +6,7 @@ import (
 
 type RateLimit struct {
 	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID    string    `gorm:"index;not null;default:default"`
 	ModuleName  string    `gorm:"index;not null"`
 	Provider    string    `gorm:"index;not null"`
 	MaxRequests int       `gorm:"not null"`
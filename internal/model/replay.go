@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReplayPending   = "pending"
+	ReplayRunning   = "running"
+	ReplayCompleted = "completed"
+	ReplayFailed    = "failed"
+)
+
+// ReplayJob re-sends a sample of a module's logged live traffic to another
+// prompt version and/or model. Without PromptVersionID the originally
+// rendered system prompt is reused; without Provider and ModelName each
+// request goes to the model that first served it.
+type ReplayJob struct {
+	ID              uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID        string    `gorm:"index;not null;default:default"`
+	ModuleName      string    `gorm:"index;not null"`
+	From            *time.Time
+	To              *time.Time
+	SampleSize      int
+	PromptVersionID *uuid.UUID `gorm:"type:uuid"`
+	Provider        string
+	ModelName       string
+	Concurrency     int
+	Status          string `gorm:"index;not null;default:pending"`
+	Error           string `gorm:"type:text"`
+	Total           int
+	Changed         int
+	Failed          int
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	CreatedAt       time.Time
+}
+
+// ReplayResult pairs one original response with its replayed counterpart.
+type ReplayResult struct {
+	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID          string     `gorm:"index;not null;default:default"`
+	JobID             uuid.UUID  `gorm:"type:uuid;index;not null"`
+	UsageLogID        uuid.UUID  `gorm:"type:uuid;not null"`
+	ReplayLogID       *uuid.UUID `gorm:"type:uuid"`
+	UserPrompt        string     `gorm:"type:text"`
+	OriginalResponse  string     `gorm:"type:text"`
+	ReplayResponse    string     `gorm:"type:text"`
+	Changed           bool
+	Error             string `gorm:"type:text"`
+	OriginalLatencyMs int64
+	ReplayLatencyMs   int64
+	OriginalCost      float64
+	ReplayCost        float64
+	CreatedAt         time.Time
+}
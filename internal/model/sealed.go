@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/abeselom-personal/go-ai-service/internal/encryption"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"gorm.io/gorm/schema"
+)
+
+// sealedPrefix marks a column value as sealed rather than plaintext.
+const sealedPrefix = "enc:"
+
+type fieldEncryption struct {
+	keys *encryption.Keyring
+	seal bool
+}
+
+var fieldKeys atomic.Pointer[fieldEncryption]
+
+func init() {
+	schema.RegisterSerializer("sealed", sealedSerializer{})
+}
+
+// UseFieldEncryption installs the keyring used by string columns tagged
+// serializer:sealed. With seal false new values are written in plaintext,
+// but values sealed earlier can still be read. A nil keyring uninstalls it.
+func UseFieldEncryption(keys *encryption.Keyring, seal bool) {
+	if keys == nil {
+		fieldKeys.Store(nil)
+		return
+	}
+	fieldKeys.Store(&fieldEncryption{keys: keys, seal: seal})
+}
+
+// FieldEncryption reports the key version sealed columns are written with
+// and whether they are sealed at all.
+func FieldEncryption() (version int, seal bool) {
+	fe := fieldKeys.Load()
+	if fe == nil || !fe.seal {
+		return 0, false
+	}
+	return fe.keys.Version(), true
+}
+
+// SealedPrefix is how values sealed with key version start, or any
+// sealed value for version 0.
+func SealedPrefix(version int) string {
+	if version == 0 {
+		return sealedPrefix
+	}
+	return fmt.Sprintf("%sv%d:", sealedPrefix, version)
+}
+
+// SealedAs reports the key version value was sealed with, or 0 when it is
+// stored in plaintext.
+func SealedAs(value string) int {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return 0
+	}
+	version, err := encryption.VersionOf(value[len(sealedPrefix):])
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// sealedSerializer stores string fields AES-GCM encrypted, authenticated
+// against their column name and the row's tenant, so a sealed value copied
+// onto another row of another tenant does not open. Empty strings are
+// stored as is so queries can still test the column for emptiness.
+//
+// The tenant may be scanned after the sealed columns, so Scan leaves the
+// values sealed and the model's AfterFind hook opens them with openSealed.
+type sealedSerializer struct{}
+
+func (sealedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into sealed field %s", dbValue, field.Name)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(stored)
+	return nil
+}
+
+func (sealedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plain, _ := fieldValue.(string)
+	fe := fieldKeys.Load()
+	if plain == "" || fe == nil || !fe.seal {
+		return plain, nil
+	}
+	tenantID := ""
+	if tf := field.Schema.LookUpField("TenantID"); tf != nil {
+		v, _ := tf.ValueOf(ctx, dst)
+		tenantID, _ = v.(string)
+	}
+	sealed, err := fe.keys.Seal([]byte(plain), sealedAAD(field.DBName, tenantID))
+	if err != nil {
+		return nil, err
+	}
+	return sealedPrefix + sealed, nil
+}
+
+// sealedAAD binds a sealed value to its column and its row's tenant. Rows
+// are stamped with their tenant before they are written; an empty one is
+// the column default.
+func sealedAAD(column, tenantID string) []byte {
+	if tenantID == "" {
+		tenantID = tenant.Default
+	}
+	return []byte(column + "\x00" + tenantID)
+}
+
+// openSealed opens the sealed values of the given columns of a row of
+// tenantID in place.
+func openSealed(tenantID string, columns map[string]*string) error {
+	for column, value := range columns {
+		if !strings.HasPrefix(*value, sealedPrefix) {
+			continue
+		}
+		fe := fieldKeys.Load()
+		if fe == nil {
+			return fmt.Errorf("column %s is encrypted but no encryption key is configured", column)
+		}
+		plain, err := fe.keys.Open((*value)[len(sealedPrefix):], sealedAAD(column, tenantID))
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+		*value = string(plain)
+	}
+	return nil
+}
@@ -2,19 +2,65 @@
 package models
 
 import (
+	"database/sql/driver"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// SystemPrompt is a stored prompt. Name is an optional slug that identifies
+// it within its module. Live prompts of a module cannot share a name; the
+// unique index covers only undeleted rows, as NULL deleted_at values would
+// never collide in an index that included the column.
 type SystemPrompt struct {
 	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	ModuleName   string    `gorm:"index;not null"`
+	TenantID     string    `gorm:"index;uniqueIndex:idx_system_prompt_name,where:deleted_at IS NULL AND name <> '';not null;default:default"`
+	ModuleName   string    `gorm:"index;uniqueIndex:idx_system_prompt_name;not null"`
+	Name         string    `gorm:"uniqueIndex:idx_system_prompt_name;not null;default:''"`
 	ModelName    string    `gorm:"index;not null"`
 	Provider     string    `gorm:"index;not null"`
 	SystemPrompt string    `gorm:"type:text;not null"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	// Variables declares the {{.name}} placeholders SystemPrompt may use.
+	Variables PromptVariables `gorm:"type:text"`
+	// ConfigManaged prompts are defined by a file in the config prompts
+	// directory and can only be changed by editing that file.
+	ConfigManaged bool `gorm:"not null;default:false"`
+	// Version is the number of the latest SystemPromptVersion and
+	// CurrentVersionID its ID.
+	Version          int
+	CurrentVersionID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
+
+const (
+	VariableTypeString  = "string"
+	VariableTypeNumber  = "number"
+	VariableTypeBoolean = "boolean"
+)
+
+type PromptVariable struct {
+	Name        string      `json:"name" yaml:"name"`
+	Type        string      `json:"type" yaml:"type"` // "string", "number" or "boolean"
+	Default     interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+	Required    bool        `json:"required" yaml:"required,omitempty"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PromptVariables is stored as a JSON array.
+type PromptVariables []PromptVariable
+
+func (v PromptVariables) Value() (driver.Value, error) {
+	return valueJSON([]PromptVariable(v), v == nil)
+}
+
+func (v *PromptVariables) Scan(src interface{}) error {
+	var out []PromptVariable
+	if err := scanJSON(src, &out, "prompt variables"); err != nil {
+		return err
+	}
+	*v = out
+	return nil
 }
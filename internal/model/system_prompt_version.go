@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SystemPromptVersion is an immutable snapshot of a SystemPrompt, written on
+// every create, update and rollback.
+type SystemPromptVersion struct {
+	ID             uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TenantID       string          `gorm:"index;not null;default:default"`
+	SystemPromptID uuid.UUID       `gorm:"type:uuid;uniqueIndex:idx_prompt_version;not null"`
+	Version        int             `gorm:"uniqueIndex:idx_prompt_version;not null"`
+	ModuleName     string          `gorm:"index;not null"`
+	ModelName      string          `gorm:"not null"`
+	Provider       string          `gorm:"not null"`
+	SystemPrompt   string          `gorm:"type:text;not null"`
+	Variables      PromptVariables `gorm:"type:text"`
+	Author         string
+	ChangeNote     string `gorm:"type:text"`
+	CreatedAt      time.Time
+}
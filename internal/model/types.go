@@ -0,0 +1,97 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// valueJSON encodes v for a text column, storing NULL for nil values.
+func valueJSON(v interface{}, isNil bool) (driver.Value, error) {
+	if isNil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// scanJSON decodes a text or blob column into dst, leaving it untouched
+// for NULL.
+func scanJSON(src interface{}, dst interface{}, typeName string) error {
+	var raw []byte
+	switch s := src.(type) {
+	case nil:
+		return nil
+	case string:
+		raw = []byte(s)
+	case []byte:
+		raw = s
+	default:
+		return fmt.Errorf("cannot scan %T into %s", src, typeName)
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("invalid %s: %w", typeName, err)
+	}
+	return nil
+}
+
+// StringList is stored as a JSON array in a text column.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return valueJSON([]string(l), l == nil)
+}
+
+func (l *StringList) Scan(src interface{}) error {
+	var out []string
+	if err := scanJSON(src, &out, "string list"); err != nil {
+		return err
+	}
+	*l = out
+	return nil
+}
+
+// Contains reports whether s is in the list.
+func (l StringList) Contains(s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONMap is a free-form JSON object stored in a text column.
+type JSONMap map[string]interface{}
+
+func (m JSONMap) Value() (driver.Value, error) {
+	return valueJSON(map[string]interface{}(m), m == nil)
+}
+
+func (m *JSONMap) Scan(src interface{}) error {
+	var out map[string]interface{}
+	if err := scanJSON(src, &out, "JSON object"); err != nil {
+		return err
+	}
+	*m = out
+	return nil
+}
+
+// EntityCounts counts detected entities by name.
+type EntityCounts map[string]int
+
+func (c EntityCounts) Value() (driver.Value, error) {
+	return valueJSON(map[string]int(c), c == nil)
+}
+
+func (c *EntityCounts) Scan(src interface{}) error {
+	var out map[string]int
+	if err := scanJSON(src, &out, "entity counts"); err != nil {
+		return err
+	}
+	*c = out
+	return nil
+}
@@ -0,0 +1,63 @@
+// internal/repository/api_key_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo(db *gorm.DB) *APIKeyRepo {
+	return &APIKeyRepo{db}
+}
+
+func (r *APIKeyRepo) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, contextTxKey, tx)
+		return fn(txCtx)
+	})
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, key *models.APIKey) error {
+	return scoped(ctx, r.db).Create(key).Error
+}
+
+func (r *APIKeyRepo) Get(ctx context.Context, id string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := scoped(ctx, r.db).First(&key, "id = ?", id).Error
+	return &key, err
+}
+
+// GetByPrefix looks a key up across all tenants: it runs before the
+// caller's tenant is known.
+func (r *APIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := getDB(ctx, r.db).WithContext(ctx).First(&key, "prefix = ?", prefix).Error
+	return &key, err
+}
+
+func (r *APIKeyRepo) List(ctx context.Context, module string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	q := scoped(ctx, r.db)
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	err := q.Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *APIKeyRepo) Save(ctx context.Context, key *models.APIKey) error {
+	return scoped(ctx, r.db).Save(key).Error
+}
+
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return getDB(ctx, r.db).WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
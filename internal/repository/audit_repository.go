@@ -0,0 +1,73 @@
+// internal/repository/audit_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type AuditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo(db *gorm.DB) *AuditRepo {
+	return &AuditRepo{db}
+}
+
+// Create writes the event in the transaction of ctx, if there is one, so
+// it is only kept when the change it describes is.
+func (r *AuditRepo) Create(ctx context.Context, e *models.AuditEvent) error {
+	return scoped(ctx, r.db).Create(e).Error
+}
+
+// AuditFilter narrows an audit listing. Zero fields match everything.
+type AuditFilter struct {
+	Module       string
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+}
+
+// List returns matching events, newest first.
+func (r *AuditRepo) List(ctx context.Context, f AuditFilter) ([]models.AuditEvent, error) {
+	q := scoped(ctx, r.db)
+	if f.Module != "" {
+		q = q.Where("module_name = ?", f.Module)
+	}
+	if f.Actor != "" {
+		q = q.Where("actor = ? OR actor_id = ?", f.Actor, f.Actor)
+	}
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.ResourceType != "" {
+		q = q.Where("resource_type = ?", f.ResourceType)
+	}
+	if f.ResourceID != "" {
+		q = q.Where("resource_id = ?", f.ResourceID)
+	}
+	if f.RequestID != "" {
+		q = q.Where("request_id = ?", f.RequestID)
+	}
+	if f.From != nil {
+		q = q.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("created_at < ?", *f.To)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+
+	var events []models.AuditEvent
+	err := q.Order("created_at DESC").Find(&events).Error
+	return events, err
+}
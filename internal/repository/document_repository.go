@@ -0,0 +1,148 @@
+// internal/repository/document_repository.go
+package repository
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DocumentRepo struct {
+	db *gorm.DB
+}
+
+func NewDocumentRepo(db *gorm.DB) *DocumentRepo {
+	return &DocumentRepo{db}
+}
+
+func (r *DocumentRepo) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, contextTxKey, tx)
+		return fn(txCtx)
+	})
+}
+
+func (r *DocumentRepo) CreateCollection(ctx context.Context, c *models.DocumentCollection) error {
+	return scoped(ctx, r.db).Create(c).Error
+}
+
+func (r *DocumentRepo) GetCollection(ctx context.Context, id string) (*models.DocumentCollection, error) {
+	var c models.DocumentCollection
+	err := scoped(ctx, r.db).First(&c, "id = ?", id).Error
+	return &c, err
+}
+
+func (r *DocumentRepo) GetCollectionByName(ctx context.Context, module, name string) (*models.DocumentCollection, error) {
+	var c models.DocumentCollection
+	err := scoped(ctx, r.db).
+		Where("module_name = ? AND name = ?", module, name).
+		First(&c).Error
+	return &c, err
+}
+
+func (r *DocumentRepo) ListCollections(ctx context.Context, module string) ([]models.DocumentCollection, error) {
+	var collections []models.DocumentCollection
+	q := scoped(ctx, r.db)
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	err := q.Order("name").Find(&collections).Error
+	return collections, err
+}
+
+func (r *DocumentRepo) DeleteCollection(ctx context.Context, id string) error {
+	db := scoped(ctx, r.db)
+	if err := db.Delete(&models.DocumentChunk{}, "collection_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := db.Delete(&models.Document{}, "collection_id = ?", id).Error; err != nil {
+		return err
+	}
+	return db.Delete(&models.DocumentCollection{}, "id = ?", id).Error
+}
+
+func (r *DocumentRepo) CreateDocument(ctx context.Context, doc *models.Document, chunks []models.DocumentChunk) error {
+	db := scoped(ctx, r.db)
+	if err := db.Create(doc).Error; err != nil {
+		return err
+	}
+	for i := range chunks {
+		chunks[i].DocumentID = doc.ID
+		chunks[i].CollectionID = doc.CollectionID
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	return db.Create(&chunks).Error
+}
+
+func (r *DocumentRepo) ListDocuments(ctx context.Context, collectionID string) ([]models.Document, error) {
+	var docs []models.Document
+	err := scoped(ctx, r.db).
+		Where("collection_id = ?", collectionID).
+		Order("created_at").
+		Find(&docs).Error
+	return docs, err
+}
+
+// SearchChunks returns the k chunks closest to query by cosine distance.
+// On Postgres this is a pgvector query; other dialects fall back to an
+// in-memory scan of the collection.
+func (r *DocumentRepo) SearchChunks(ctx context.Context, collectionID uuid.UUID, query models.Vector, k int) ([]models.DocumentChunk, error) {
+	db := scoped(ctx, r.db)
+
+	if db.Dialector.Name() == "postgres" {
+		var chunks []struct {
+			models.DocumentChunk
+			Distance float64
+		}
+		err := db.Model(&models.DocumentChunk{}).
+			Select("*, embedding <=> ?::vector AS distance", query).
+			Where("collection_id = ?", collectionID).
+			Order("distance").
+			Limit(k).
+			Scan(&chunks).Error
+		if err != nil {
+			return nil, err
+		}
+		out := make([]models.DocumentChunk, len(chunks))
+		for i, c := range chunks {
+			out[i] = c.DocumentChunk
+			out[i].Score = 1 - c.Distance
+		}
+		return out, nil
+	}
+
+	var chunks []models.DocumentChunk
+	if err := db.Where("collection_id = ?", collectionID).Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	for i := range chunks {
+		chunks[i].Score = cosineSimilarity(query, chunks[i].Embedding)
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].Score > chunks[j].Score })
+	if len(chunks) > k {
+		chunks = chunks[:k]
+	}
+	return chunks, nil
+}
+
+func cosineSimilarity(a, b models.Vector) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,84 @@
+// internal/repository/eval_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type EvalRepo struct {
+	db *gorm.DB
+}
+
+func NewEvalRepo(db *gorm.DB) *EvalRepo {
+	return &EvalRepo{db}
+}
+
+// CreateDataset stores the dataset together with its cases.
+func (r *EvalRepo) CreateDataset(ctx context.Context, d *models.EvalDataset) error {
+	return scoped(ctx, r.db).Create(d).Error
+}
+
+func (r *EvalRepo) GetDataset(ctx context.Context, id string) (*models.EvalDataset, error) {
+	var d models.EvalDataset
+	err := scoped(ctx, r.db).
+		Preload("Cases", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&d, "id = ?", id).Error
+	return &d, err
+}
+
+func (r *EvalRepo) ListDatasets(ctx context.Context, module string) ([]models.EvalDataset, error) {
+	var datasets []models.EvalDataset
+	q := scoped(ctx, r.db)
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	err := q.Order("module_name, name").Find(&datasets).Error
+	return datasets, err
+}
+
+func (r *EvalRepo) AddCases(ctx context.Context, cases []models.EvalCase) error {
+	return scoped(ctx, r.db).Create(&cases).Error
+}
+
+func (r *EvalRepo) CreateRun(ctx context.Context, run *models.EvalRun) error {
+	return scoped(ctx, r.db).Create(run).Error
+}
+
+func (r *EvalRepo) GetRun(ctx context.Context, id string) (*models.EvalRun, error) {
+	var run models.EvalRun
+	err := scoped(ctx, r.db).First(&run, "id = ?", id).Error
+	return &run, err
+}
+
+func (r *EvalRepo) ListRuns(ctx context.Context, module, datasetID string) ([]models.EvalRun, error) {
+	var runs []models.EvalRun
+	q := scoped(ctx, r.db)
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	if datasetID != "" {
+		q = q.Where("dataset_id = ?", datasetID)
+	}
+	err := q.Order("created_at DESC").Find(&runs).Error
+	return runs, err
+}
+
+func (r *EvalRepo) SaveRun(ctx context.Context, run *models.EvalRun) error {
+	return scoped(ctx, r.db).Save(run).Error
+}
+
+func (r *EvalRepo) CreateResult(ctx context.Context, result *models.EvalResult) error {
+	return scoped(ctx, r.db).Create(result).Error
+}
+
+func (r *EvalRepo) ListResults(ctx context.Context, runID string) ([]models.EvalResult, error) {
+	var results []models.EvalResult
+	err := scoped(ctx, r.db).
+		Where("run_id = ?", runID).
+		Order("created_at").
+		Find(&results).Error
+	return results, err
+}
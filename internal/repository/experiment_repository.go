@@ -0,0 +1,100 @@
+// internal/repository/experiment_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type ExperimentRepo struct {
+	db *gorm.DB
+}
+
+func NewExperimentRepo(db *gorm.DB) *ExperimentRepo {
+	return &ExperimentRepo{db}
+}
+
+func (r *ExperimentRepo) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, contextTxKey, tx)
+		return fn(txCtx)
+	})
+}
+
+// Create stores the experiment together with its variants.
+func (r *ExperimentRepo) Create(ctx context.Context, e *models.Experiment) error {
+	return scoped(ctx, r.db).Create(e).Error
+}
+
+func (r *ExperimentRepo) Get(ctx context.Context, id string) (*models.Experiment, error) {
+	var e models.Experiment
+	err := scoped(ctx, r.db).Preload("Variants").First(&e, "id = ?", id).Error
+	return &e, err
+}
+
+func (r *ExperimentRepo) List(ctx context.Context, module string) ([]models.Experiment, error) {
+	var experiments []models.Experiment
+	q := scoped(ctx, r.db).Preload("Variants")
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	err := q.Order("created_at DESC").Find(&experiments).Error
+	return experiments, err
+}
+
+// GetRunning returns the module's running experiment, if any.
+func (r *ExperimentRepo) GetRunning(ctx context.Context, module string) (*models.Experiment, error) {
+	var e models.Experiment
+	err := scoped(ctx, r.db).Preload("Variants").
+		Where("module_name = ? AND status = ?", module, models.ExperimentRunning).
+		First(&e).Error
+	return &e, err
+}
+
+// SetStatus moves the experiment to status if its current status is one
+// of from, and reports whether it did.
+func (r *ExperimentRepo) SetStatus(ctx context.Context, id, status string, from ...string) (bool, error) {
+	res := scoped(ctx, r.db).Model(&models.Experiment{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", status)
+	return res.RowsAffected > 0, res.Error
+}
+
+// VariantStats is the usage of one variant aggregated from AIUsageLog.
+type VariantStats struct {
+	VariantID    string
+	Requests     int64
+	Errors       int64
+	AvgLatencyMs float64
+	TotalCost    float64
+}
+
+func (r *ExperimentRepo) VariantStats(ctx context.Context, experimentID string) ([]VariantStats, error) {
+	var stats []VariantStats
+	err := scoped(ctx, r.db).Model(&models.AIUsageLog{}).
+		Select("variant_id, COUNT(*) AS requests, "+
+			"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS errors, "+
+			"AVG(latency_ms) AS avg_latency_ms, SUM(cost) AS total_cost", models.UsageStatusError).
+		Where("experiment_id = ?", experimentID).
+		Group("variant_id").
+		Scan(&stats).Error
+	return stats, err
+}
+
+type VariantFeedbackStats struct {
+	VariantID string
+	FeedbackStats
+}
+
+func (r *ExperimentRepo) VariantFeedback(ctx context.Context, experimentID string) ([]VariantFeedbackStats, error) {
+	var stats []VariantFeedbackStats
+	err := scoped(ctx, r.db).Table("feedbacks").
+		Joins("JOIN ai_usage_logs ON ai_usage_logs.id = feedbacks.usage_log_id").
+		Select("ai_usage_logs.variant_id, "+feedbackAggregates).
+		Where("ai_usage_logs.experiment_id = ?", experimentID).
+		Group("ai_usage_logs.variant_id").
+		Scan(&stats).Error
+	return stats, err
+}
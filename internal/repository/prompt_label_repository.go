@@ -0,0 +1,62 @@
+// internal/repository/prompt_label_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpsertLabel points the label at l.VersionID, creating it if needed.
+func (r *SystemPromptRepo) UpsertLabel(ctx context.Context, l *models.PromptLabel) error {
+	return scoped(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "system_prompt_id"}, {Name: "label"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version_id", "version", "updated_by", "updated_at"}),
+	}).Create(l).Error
+}
+
+// livePromptLabels keeps the labels of soft-deleted prompts out of a query.
+// Deleting a prompt leaves its labels in place so that Restore brings them
+// back as they were.
+func livePromptLabels(db *gorm.DB) *gorm.DB {
+	return db.Where("system_prompt_id IN (?)",
+		db.Session(&gorm.Session{NewDB: true}).Model(&models.SystemPrompt{}).Select("id"))
+}
+
+func (r *SystemPromptRepo) GetLabel(ctx context.Context, promptID, label string) (*models.PromptLabel, error) {
+	var l models.PromptLabel
+	err := scoped(ctx, r.db).Scopes(livePromptLabels).
+		Where("system_prompt_id = ? AND label = ?", promptID, label).
+		First(&l).Error
+	return &l, err
+}
+
+// GetLabelForUpdate loads a label and locks its row until the surrounding
+// transaction ends.
+func (r *SystemPromptRepo) GetLabelForUpdate(ctx context.Context, promptID, label string) (*models.PromptLabel, error) {
+	var l models.PromptLabel
+	err := scoped(ctx, r.db).Scopes(livePromptLabels).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("system_prompt_id = ? AND label = ?", promptID, label).
+		First(&l).Error
+	return &l, err
+}
+
+func (r *SystemPromptRepo) FindLabelsInModule(ctx context.Context, module, label string) ([]models.PromptLabel, error) {
+	var labels []models.PromptLabel
+	err := scoped(ctx, r.db).Scopes(livePromptLabels).
+		Where("module_name = ? AND label = ?", module, label).
+		Find(&labels).Error
+	return labels, err
+}
+
+func (r *SystemPromptRepo) ListLabels(ctx context.Context, promptID string) ([]models.PromptLabel, error) {
+	var labels []models.PromptLabel
+	err := scoped(ctx, r.db).
+		Where("system_prompt_id = ?", promptID).
+		Order("label").
+		Find(&labels).Error
+	return labels, err
+}
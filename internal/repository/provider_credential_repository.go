@@ -0,0 +1,78 @@
+// internal/repository/provider_credential_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type ProviderCredentialRepo struct {
+	db *gorm.DB
+}
+
+func NewProviderCredentialRepo(db *gorm.DB) *ProviderCredentialRepo {
+	return &ProviderCredentialRepo{db}
+}
+
+func (r *ProviderCredentialRepo) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, contextTxKey, tx)
+		return fn(txCtx)
+	})
+}
+
+func (r *ProviderCredentialRepo) Save(ctx context.Context, c *models.ProviderCredential) error {
+	return scoped(ctx, r.db).Save(c).Error
+}
+
+func (r *ProviderCredentialRepo) Get(ctx context.Context, id string) (*models.ProviderCredential, error) {
+	var c models.ProviderCredential
+	err := scoped(ctx, r.db).First(&c, "id = ?", id).Error
+	return &c, err
+}
+
+// Find returns the credential registered for exactly this module and
+// provider; an empty module is the tenant-wide credential.
+func (r *ProviderCredentialRepo) Find(ctx context.Context, module, provider string) (*models.ProviderCredential, error) {
+	var c models.ProviderCredential
+	err := scoped(ctx, r.db).
+		Where("module_name = ? AND provider = ?", module, provider).
+		First(&c).Error
+	return &c, err
+}
+
+func (r *ProviderCredentialRepo) List(ctx context.Context, module string) ([]models.ProviderCredential, error) {
+	var creds []models.ProviderCredential
+	q := scoped(ctx, r.db)
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	err := q.Order("module_name, provider").Find(&creds).Error
+	return creds, err
+}
+
+func (r *ProviderCredentialRepo) Delete(ctx context.Context, id string) error {
+	return scoped(ctx, r.db).Delete(&models.ProviderCredential{}, "id = ?", id).Error
+}
+
+// ListStale returns up to limit credentials of any tenant sealed with a
+// key version other than version.
+func (r *ProviderCredentialRepo) ListStale(ctx context.Context, version, limit int) ([]models.ProviderCredential, error) {
+	var creds []models.ProviderCredential
+	err := getDB(ctx, r.db).WithContext(ctx).
+		Where("key_version <> ?", version).
+		Order("id").
+		Limit(limit).
+		Find(&creds).Error
+	return creds, err
+}
+
+// Reseal replaces a credential's sealed key, unless it was changed since
+// it was read with oldSealed.
+func (r *ProviderCredentialRepo) Reseal(ctx context.Context, id, oldSealed, sealed string, version int) error {
+	return getDB(ctx, r.db).WithContext(ctx).Model(&models.ProviderCredential{}).
+		Where("id = ? AND sealed_key = ?", id, oldSealed).
+		Updates(map[string]interface{}{"sealed_key": sealed, "key_version": version}).Error
+}
@@ -0,0 +1,75 @@
+// internal/repository/provider_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// ProviderRepo stores the providers and models registered through the
+// API. They are shared by all tenants, so its queries are not scoped.
+type ProviderRepo struct {
+	db *gorm.DB
+}
+
+func NewProviderRepo(db *gorm.DB) *ProviderRepo {
+	return &ProviderRepo{db}
+}
+
+func (r *ProviderRepo) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, contextTxKey, tx)
+		return fn(txCtx)
+	})
+}
+
+func (r *ProviderRepo) global(ctx context.Context) *gorm.DB {
+	return getDB(ctx, r.db).WithContext(ctx)
+}
+
+func (r *ProviderRepo) ListProviders(ctx context.Context) ([]models.Provider, error) {
+	var providers []models.Provider
+	err := r.global(ctx).Order("name").Find(&providers).Error
+	return providers, err
+}
+
+func (r *ProviderRepo) GetProvider(ctx context.Context, name string) (*models.Provider, error) {
+	var p models.Provider
+	err := r.global(ctx).First(&p, "name = ?", name).Error
+	return &p, err
+}
+
+func (r *ProviderRepo) SaveProvider(ctx context.Context, p *models.Provider) error {
+	return r.global(ctx).Save(p).Error
+}
+
+// DeleteProvider removes a provider together with its models.
+func (r *ProviderRepo) DeleteProvider(ctx context.Context, name string) error {
+	db := r.global(ctx)
+	if err := db.Delete(&models.Model{}, "provider_name = ?", name).Error; err != nil {
+		return err
+	}
+	return db.Delete(&models.Provider{}, "name = ?", name).Error
+}
+
+func (r *ProviderRepo) ListModels(ctx context.Context) ([]models.Model, error) {
+	var ms []models.Model
+	err := r.global(ctx).Order("provider_name, name").Find(&ms).Error
+	return ms, err
+}
+
+func (r *ProviderRepo) GetModel(ctx context.Context, provider, name string) (*models.Model, error) {
+	var m models.Model
+	err := r.global(ctx).First(&m, "provider_name = ? AND name = ?", provider, name).Error
+	return &m, err
+}
+
+func (r *ProviderRepo) SaveModel(ctx context.Context, m *models.Model) error {
+	return r.global(ctx).Save(m).Error
+}
+
+func (r *ProviderRepo) DeleteModel(ctx context.Context, provider, name string) error {
+	return r.global(ctx).Delete(&models.Model{}, "provider_name = ? AND name = ?", provider, name).Error
+}
@@ -0,0 +1,35 @@
+// internal/repository/rate_limit_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type RateLimitRepo struct {
+	db *gorm.DB
+}
+
+func NewRateLimitRepo(db *gorm.DB) *RateLimitRepo {
+	return &RateLimitRepo{db}
+}
+
+func (r *RateLimitRepo) Save(ctx context.Context, l *models.RateLimit) error {
+	return scoped(ctx, r.db).Save(l).Error
+}
+
+func (r *RateLimitRepo) List(ctx context.Context, module string) ([]models.RateLimit, error) {
+	var limits []models.RateLimit
+	q := scoped(ctx, r.db)
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	err := q.Order("module_name, provider").Find(&limits).Error
+	return limits, err
+}
+
+func (r *RateLimitRepo) Delete(ctx context.Context, id string) error {
+	return scoped(ctx, r.db).Delete(&models.RateLimit{}, "id = ?", id).Error
+}
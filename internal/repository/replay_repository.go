@@ -0,0 +1,70 @@
+// internal/repository/replay_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type ReplayRepo struct {
+	db *gorm.DB
+}
+
+func NewReplayRepo(db *gorm.DB) *ReplayRepo {
+	return &ReplayRepo{db}
+}
+
+func (r *ReplayRepo) CreateJob(ctx context.Context, job *models.ReplayJob) error {
+	return scoped(ctx, r.db).Create(job).Error
+}
+
+func (r *ReplayRepo) GetJob(ctx context.Context, id string) (*models.ReplayJob, error) {
+	var job models.ReplayJob
+	err := scoped(ctx, r.db).First(&job, "id = ?", id).Error
+	return &job, err
+}
+
+func (r *ReplayRepo) ListJobs(ctx context.Context, module string) ([]models.ReplayJob, error) {
+	var jobs []models.ReplayJob
+	q := scoped(ctx, r.db)
+	if module != "" {
+		q = q.Where("module_name = ?", module)
+	}
+	err := q.Order("created_at DESC").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *ReplayRepo) SaveJob(ctx context.Context, job *models.ReplayJob) error {
+	return scoped(ctx, r.db).Save(job).Error
+}
+
+// SampleLogs picks up to limit random successful live completions matching
+// filter. Logs written before user prompts were stored separately cannot
+// be replayed and are skipped.
+func (r *ReplayRepo) SampleLogs(ctx context.Context, filter UsageFilter, limit int) ([]models.AIUsageLog, error) {
+	var logs []models.AIUsageLog
+	q := filter.apply(scoped(ctx, r.db), "ai_usage_logs").
+		Where("kind = ? AND status = ? AND source = ? AND user_prompt <> ?",
+			models.UsageKindCompletion, models.UsageStatusOK, "", "").
+		Order("RANDOM()")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.Find(&logs).Error
+	return logs, err
+}
+
+func (r *ReplayRepo) CreateResult(ctx context.Context, result *models.ReplayResult) error {
+	return scoped(ctx, r.db).Create(result).Error
+}
+
+func (r *ReplayRepo) ListResults(ctx context.Context, jobID string) ([]models.ReplayResult, error) {
+	var results []models.ReplayResult
+	err := scoped(ctx, r.db).
+		Where("job_id = ?", jobID).
+		Order("created_at").
+		Find(&results).Error
+	return results, err
+}
@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
 	"gorm.io/gorm"
 )
 
@@ -20,3 +21,10 @@ func getDB(ctx context.Context, defaultDB *gorm.DB) *gorm.DB {
 	}
 	return defaultDB
 }
+
+// scoped returns the database of ctx limited to the tenant of ctx. All
+// repository queries go through it so one tenant never sees another's rows.
+// The result is a session, so it can be reused for several statements.
+func scoped(ctx context.Context, defaultDB *gorm.DB) *gorm.DB {
+	return getDB(ctx, defaultDB).WithContext(ctx).Scopes(tenant.Scope(ctx)).Session(&gorm.Session{})
+}
@@ -0,0 +1,117 @@
+// internal/repository/system_prompt_query.go
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// promptSearchVector is the document prompt search matches on Postgres.
+// The search index is built on the same expression.
+const promptSearchVector = "to_tsvector('simple', system_prompt)"
+
+// PromptSortColumns are the columns prompts can be listed by.
+var PromptSortColumns = []string{"created_at", "updated_at"}
+
+// PromptQuery selects one page of prompts. Zero fields match everything.
+type PromptQuery struct {
+	Module   string
+	Name     string
+	Provider string
+	Model    string
+	// Search matches words of the prompt text.
+	Search string
+	// Deleted lists the prompts in the trash instead of the live ones.
+	Deleted bool
+	// SortBy is one of PromptSortColumns; newest first unless Ascending.
+	SortBy    string
+	Ascending bool
+	// After continues the listing after the last prompt of a page.
+	After *PromptCursor
+	Limit int
+}
+
+// PromptCursor is the position of a prompt in a listing: the value of the
+// sort column and, to break ties, the ID.
+type PromptCursor struct {
+	At time.Time `json:"at"`
+	ID uuid.UUID `json:"id"`
+}
+
+// ListPage returns up to q.Limit prompts matching q and how many match in
+// all, ignoring q.After.
+func (r *SystemPromptRepo) ListPage(ctx context.Context, q PromptQuery) ([]models.SystemPrompt, int64, error) {
+	db := scoped(ctx, r.db)
+	if q.Deleted {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if q.Module != "" {
+		db = db.Where("module_name = ?", q.Module)
+	}
+	if q.Name != "" {
+		db = db.Where("name = ?", q.Name)
+	}
+	if q.Provider != "" {
+		db = db.Where("provider = ?", q.Provider)
+	}
+	if q.Model != "" {
+		db = db.Where("model_name = ?", q.Model)
+	}
+	if q.Search != "" {
+		if db.Dialector.Name() == "postgres" {
+			db = db.Where(promptSearchVector+" @@ plainto_tsquery('simple', ?)", q.Search)
+		} else {
+			db = db.Where(`LOWER(system_prompt) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(q.Search))+"%")
+		}
+	}
+	db = db.Model(&models.SystemPrompt{}).Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, dir, cmp := "created_at", "DESC", "<"
+	for _, c := range PromptSortColumns {
+		if c == q.SortBy {
+			column = c
+		}
+	}
+	if q.Ascending {
+		dir, cmp = "ASC", ">"
+	}
+	page := db
+	if q.After != nil {
+		page = page.Where("("+column+" "+cmp+" ? OR ("+column+" = ? AND id "+cmp+" ?))",
+			q.After.At, q.After.At, q.After.ID)
+	}
+	if q.Limit > 0 {
+		page = page.Limit(q.Limit)
+	}
+
+	var prompts []models.SystemPrompt
+	err := page.Order(column + " " + dir).Order("id " + dir).Find(&prompts).Error
+	return prompts, total, err
+}
+
+// EnsureSearchIndex creates the full-text index prompt search uses on
+// Postgres. AutoMigrate cannot declare expression indexes.
+func (r *SystemPromptRepo) EnsureSearchIndex(ctx context.Context) error {
+	if r.db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return r.db.WithContext(ctx).Exec(
+		"CREATE INDEX IF NOT EXISTS idx_system_prompts_search ON system_prompts USING GIN (" + promptSearchVector + ")",
+	).Error
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -3,9 +3,11 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	models "github.com/abeselom-personal/go-ai-service/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SystemPromptRepo struct {
@@ -16,33 +18,89 @@ func NewSystemPromptRepo(db *gorm.DB) *SystemPromptRepo {
 	return &SystemPromptRepo{db}
 }
 
+// WithTransaction runs fn in a transaction. Inside a transaction already
+// carried by ctx it runs in a nested one (a savepoint), so several
+// service calls can be applied or rolled back together.
 func (r *SystemPromptRepo) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return getDB(ctx, r.db).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		txCtx := context.WithValue(ctx, contextTxKey, tx)
 		return fn(txCtx)
 	})
 }
 
 func (r *SystemPromptRepo) Create(ctx context.Context, sp *models.SystemPrompt) error {
-	return getDB(ctx, r.db).WithContext(ctx).Create(sp).Error
+	return scoped(ctx, r.db).Create(sp).Error
 }
 
-func (r *SystemPromptRepo) GetByHash(ctx context.Context, hash string) (*models.SystemPrompt, error) {
+func (r *SystemPromptRepo) GetByID(ctx context.Context, id string) (*models.SystemPrompt, error) {
 	var sp models.SystemPrompt
-	err := getDB(ctx, r.db).WithContext(ctx).Where("prompt_hash = ?", hash).First(&sp).Error
+	err := scoped(ctx, r.db).First(&sp, "id = ?", id).Error
+	return &sp, err
+}
+
+// GetByIDForUpdate loads a prompt and locks its row until the surrounding
+// transaction ends.
+func (r *SystemPromptRepo) GetByIDForUpdate(ctx context.Context, id string) (*models.SystemPrompt, error) {
+	var sp models.SystemPrompt
+	err := scoped(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&sp, "id = ?", id).Error
+	return &sp, err
+}
+
+// GetByName finds the live prompt of module with the given name.
+func (r *SystemPromptRepo) GetByName(ctx context.Context, module, name string) (*models.SystemPrompt, error) {
+	var sp models.SystemPrompt
+	err := scoped(ctx, r.db).Where("module_name = ? AND name = ?", module, name).First(&sp).Error
 	return &sp, err
 }
 
 func (r *SystemPromptRepo) Update(ctx context.Context, sp *models.SystemPrompt) error {
-	return getDB(ctx, r.db).WithContext(ctx).Save(sp).Error
+	return scoped(ctx, r.db).Save(sp).Error
 }
 
 func (r *SystemPromptRepo) Delete(ctx context.Context, id string) error {
-	return getDB(ctx, r.db).WithContext(ctx).Delete(&models.SystemPrompt{}, "id = ?", id).Error
+	return scoped(ctx, r.db).Delete(&models.SystemPrompt{}, "id = ?", id).Error
 }
 
 func (r *SystemPromptRepo) List(ctx context.Context) ([]models.SystemPrompt, error) {
 	var prompts []models.SystemPrompt
-	err := getDB(ctx, r.db).WithContext(ctx).Find(&prompts).Error
+	err := scoped(ctx, r.db).Find(&prompts).Error
+	return prompts, err
+}
+
+// GetWithDeleted finds a prompt whether or not it has been deleted.
+func (r *SystemPromptRepo) GetWithDeleted(ctx context.Context, id string) (*models.SystemPrompt, error) {
+	var sp models.SystemPrompt
+	err := scoped(ctx, r.db).Unscoped().First(&sp, "id = ?", id).Error
+	return &sp, err
+}
+
+func (r *SystemPromptRepo) Restore(ctx context.Context, id string) error {
+	return scoped(ctx, r.db).Unscoped().Model(&models.SystemPrompt{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+// Purge removes a prompt for good, with its versions and labels. Usage
+// logs, eval results and experiments keep the version IDs they recorded.
+func (r *SystemPromptRepo) Purge(ctx context.Context, id string) error {
+	db := scoped(ctx, r.db)
+	if err := db.Delete(&models.PromptLabel{}, "system_prompt_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := db.Delete(&models.SystemPromptVersion{}, "system_prompt_id = ?", id).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Delete(&models.SystemPrompt{}, "id = ?", id).Error
+}
+
+// ListDeletedBefore returns up to limit prompts of any tenant deleted
+// before cutoff.
+func (r *SystemPromptRepo) ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.SystemPrompt, error) {
+	var prompts []models.SystemPrompt
+	err := getDB(ctx, r.db).WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&prompts).Error
 	return prompts, err
 }
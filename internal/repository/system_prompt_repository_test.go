@@ -7,22 +7,13 @@ import (
 
 	models "github.com/abeselom-personal/go-ai-service/internal/model"
 	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
-func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	assert.NoError(t, err)
-	err = db.AutoMigrate(&models.SystemPrompt{})
-	assert.NoError(t, err)
-	return db
-}
-
 func TestSystemPromptRepo_CRUD(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t, models.All()...)
 	repo := repository.NewSystemPromptRepo(db)
 	ctx := context.Background()
 
@@ -30,16 +21,16 @@ func TestSystemPromptRepo_CRUD(t *testing.T) {
 	prompt := &models.SystemPrompt{
 		ID:           uuid.New(),
 		ModuleName:   "test-module",
+		Name:         "weather",
 		Provider:     "ChatGPT",
+		ModelName:    "gpt-4",
 		SystemPrompt: "You are a helper.",
-		UserPrompt:   "What's the weather?",
-		PromptHash:   "abc123",
 	}
 	err := repo.Create(ctx, prompt)
 	assert.NoError(t, err)
 
 	// Get
-	fetched, err := repo.GetByHash(ctx, "abc123")
+	fetched, err := repo.GetByName(ctx, "test-module", "weather")
 	assert.NoError(t, err)
 	assert.Equal(t, prompt.ID, fetched.ID)
 
@@ -48,10 +39,14 @@ func TestSystemPromptRepo_CRUD(t *testing.T) {
 	err = repo.Update(ctx, fetched)
 	assert.NoError(t, err)
 
-	updated, err := repo.GetByHash(ctx, "abc123")
+	updated, err := repo.GetByID(ctx, prompt.ID.String())
 	assert.NoError(t, err)
 	assert.Equal(t, "You are a very helpful assistant.", updated.SystemPrompt)
 
+	// Names are unique among the live prompts of a module.
+	twin := &models.SystemPrompt{ID: uuid.New(), ModuleName: "test-module", Name: "weather", Provider: "ChatGPT", ModelName: "gpt-4"}
+	assert.Error(t, repo.Create(ctx, twin))
+
 	// List
 	all, err := repo.List(ctx)
 	assert.NoError(t, err)
@@ -61,6 +56,7 @@ func TestSystemPromptRepo_CRUD(t *testing.T) {
 	err = repo.Delete(ctx, prompt.ID.String())
 	assert.NoError(t, err)
 
-	_, err = repo.GetByHash(ctx, "abc123")
+	_, err = repo.GetByName(ctx, "test-module", "weather")
 	assert.Error(t, err)
+	assert.NoError(t, repo.Create(ctx, twin), "a deleted prompt frees its name")
 }
@@ -0,0 +1,35 @@
+// internal/repository/system_prompt_version_repository.go
+package repository
+
+import (
+	"context"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+)
+
+func (r *SystemPromptRepo) CreateVersion(ctx context.Context, v *models.SystemPromptVersion) error {
+	return scoped(ctx, r.db).Create(v).Error
+}
+
+func (r *SystemPromptRepo) ListVersions(ctx context.Context, promptID string) ([]models.SystemPromptVersion, error) {
+	var versions []models.SystemPromptVersion
+	err := scoped(ctx, r.db).
+		Where("system_prompt_id = ?", promptID).
+		Order("version DESC").
+		Find(&versions).Error
+	return versions, err
+}
+
+func (r *SystemPromptRepo) GetVersion(ctx context.Context, promptID string, version int) (*models.SystemPromptVersion, error) {
+	var v models.SystemPromptVersion
+	err := scoped(ctx, r.db).
+		Where("system_prompt_id = ? AND version = ?", promptID, version).
+		First(&v).Error
+	return &v, err
+}
+
+func (r *SystemPromptRepo) GetVersionByID(ctx context.Context, id string) (*models.SystemPromptVersion, error) {
+	var v models.SystemPromptVersion
+	err := scoped(ctx, r.db).First(&v, "id = ?", id).Error
+	return &v, err
+}
@@ -0,0 +1,165 @@
+// internal/repository/usage_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type UsageRepo struct {
+	db *gorm.DB
+}
+
+func NewUsageRepo(db *gorm.DB) *UsageRepo {
+	return &UsageRepo{db}
+}
+
+func (r *UsageRepo) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, contextTxKey, tx)
+		return fn(txCtx)
+	})
+}
+
+func (r *UsageRepo) GetLog(ctx context.Context, id string) (*models.AIUsageLog, error) {
+	var l models.AIUsageLog
+	err := scoped(ctx, r.db).First(&l, "id = ?", id).Error
+	return &l, err
+}
+
+// Flag marks a logged response as bad so it is no longer served from cache.
+// Every log of the same request is flagged too, as any of them could
+// otherwise be served in its place.
+func (r *UsageRepo) Flag(ctx context.Context, l *models.AIUsageLog) error {
+	q := scoped(ctx, r.db).Model(&models.AIUsageLog{})
+	if l.PromptHash != "" {
+		q = q.Where("id = ? OR prompt_hash = ?", l.ID, l.PromptHash)
+	} else {
+		q = q.Where("id = ?", l.ID)
+	}
+	return q.Update("flagged", true).Error
+}
+
+// ListToReseal returns up to limit logs of any tenant after afterID, in ID
+// order, with a body not stored as want: the prefix of values sealed with
+// the current key, or "" when bodies are stored in plaintext.
+func (r *UsageRepo) ListToReseal(ctx context.Context, afterID, want string, limit int) ([]models.AIUsageLog, error) {
+	q := getDB(ctx, r.db).WithContext(ctx)
+	if want != "" {
+		like := want + "%"
+		q = q.Where("(request <> '' AND request NOT LIKE ?) OR (response <> '' AND response NOT LIKE ?) OR "+
+			"(user_prompt <> '' AND user_prompt NOT LIKE ?)", like, like, like)
+	} else {
+		like := models.SealedPrefix(0) + "%"
+		q = q.Where("request LIKE ? OR response LIKE ? OR user_prompt LIKE ?", like, like, like)
+	}
+	if afterID != "" {
+		q = q.Where("id > ?", afterID)
+	}
+	var logs []models.AIUsageLog
+	err := q.Order("id").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// SaveBodies rewrites a log's request and response bodies, which stores
+// them as the current field encryption settings dictate.
+func (r *UsageRepo) SaveBodies(ctx context.Context, l *models.AIUsageLog) error {
+	return getDB(ctx, r.db).WithContext(ctx).Model(l).
+		Select("request", "response", "user_prompt").
+		Updates(l).Error
+}
+
+func (r *UsageRepo) CreateFeedback(ctx context.Context, f *models.Feedback) error {
+	return scoped(ctx, r.db).Create(f).Error
+}
+
+func (r *UsageRepo) ListFeedback(ctx context.Context, usageLogID string) ([]models.Feedback, error) {
+	var feedback []models.Feedback
+	err := scoped(ctx, r.db).
+		Where("usage_log_id = ?", usageLogID).
+		Order("created_at").
+		Find(&feedback).Error
+	return feedback, err
+}
+
+type UsageFilter struct {
+	Module string
+	From   *time.Time
+	To     *time.Time
+}
+
+func (f UsageFilter) apply(q *gorm.DB, table string) *gorm.DB {
+	if f.Module != "" {
+		q = q.Where(table+".module_name = ?", f.Module)
+	}
+	if f.From != nil {
+		q = q.Where(table+".used_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where(table+".used_at < ?", *f.To)
+	}
+	return q
+}
+
+type UsageStats struct {
+	ModuleName       string
+	Provider         string
+	ModelName        string
+	Kind             string
+	Requests         int64
+	Errors           int64
+	AvgLatencyMs     float64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalCost        float64
+}
+
+// FeedbackStats aggregates Feedback rows.
+type FeedbackStats struct {
+	FeedbackCount int64
+	ThumbsUp      int64
+	ThumbsDown    int64
+	AvgScore      *float64
+}
+
+type UsageFeedbackStats struct {
+	ModuleName string
+	Provider   string
+	ModelName  string
+	Kind       string
+	FeedbackStats
+}
+
+const feedbackAggregates = "COUNT(feedbacks.id) AS feedback_count, " +
+	"SUM(CASE WHEN feedbacks.thumbs = 'up' THEN 1 ELSE 0 END) AS thumbs_up, " +
+	"SUM(CASE WHEN feedbacks.thumbs = 'down' THEN 1 ELSE 0 END) AS thumbs_down, " +
+	"AVG(feedbacks.score) AS avg_score"
+
+func (r *UsageRepo) UsageStats(ctx context.Context, f UsageFilter) ([]UsageStats, error) {
+	var stats []UsageStats
+	q := scoped(ctx, r.db).Model(&models.AIUsageLog{}).
+		Select("module_name, provider, model_name, kind, COUNT(*) AS requests, "+
+			"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS errors, "+
+			"AVG(latency_ms) AS avg_latency_ms, SUM(prompt_tokens) AS prompt_tokens, "+
+			"SUM(completion_tokens) AS completion_tokens, SUM(cost) AS total_cost", models.UsageStatusError)
+	err := f.apply(q, "ai_usage_logs").
+		Group("module_name, provider, model_name, kind").
+		Order("module_name, provider, model_name, kind").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *UsageRepo) FeedbackStats(ctx context.Context, f UsageFilter) ([]UsageFeedbackStats, error) {
+	var stats []UsageFeedbackStats
+	q := scoped(ctx, r.db).Table("feedbacks").
+		Joins("JOIN ai_usage_logs ON ai_usage_logs.id = feedbacks.usage_log_id").
+		Select("ai_usage_logs.module_name, ai_usage_logs.provider, ai_usage_logs.model_name, " +
+			"ai_usage_logs.kind, " + feedbackAggregates)
+	err := f.apply(q, "ai_usage_logs").
+		Group("ai_usage_logs.module_name, ai_usage_logs.provider, ai_usage_logs.model_name, ai_usage_logs.kind").
+		Scan(&stats).Error
+	return stats, err
+}
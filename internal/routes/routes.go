@@ -2,11 +2,14 @@
 package routes
 
 import (
+	"fmt"
 	"html/template"
 	"net/http"
 
 	"github.com/abeselom-personal/go-ai-service/internal/config"
 	"github.com/abeselom-personal/go-ai-service/internal/controller"
+	"github.com/abeselom-personal/go-ai-service/internal/middleware"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
 	"github.com/abeselom-personal/go-ai-service/internal/repository"
 	"github.com/abeselom-personal/go-ai-service/internal/service"
 	"github.com/gin-gonic/gin"
@@ -15,8 +18,46 @@ import (
 
 func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	repo := repository.NewSystemPromptRepo(db)
-	svc := service.NewSystemPromptService(db, repo, cfg)
+	auditSvc := service.NewAuditService(repository.NewAuditRepo(db))
+	providerSvc := service.NewProviderService(repository.NewProviderRepo(db), auditSvc, cfg)
+	embeddingSvc := service.NewEmbeddingService(db, cfg)
+	docSvc := service.NewDocumentService(repository.NewDocumentRepo(db), embeddingSvc, cfg)
+	svc := service.NewSystemPromptService(db, repo, cfg, docSvc)
 	ctrl := controller.NewSystemPromptController(svc)
+	embeddingCtrl := controller.NewEmbeddingController(embeddingSvc)
+	docCtrl := controller.NewDocumentController(docSvc)
+	usageCtrl := controller.NewUsageController(service.NewUsageService(repository.NewUsageRepo(db)))
+	experimentCtrl := controller.NewExperimentController(
+		service.NewExperimentService(repository.NewExperimentRepo(db), repo, providerSvc))
+	evalCtrl := controller.NewEvalController(service.NewEvalService(repository.NewEvalRepo(db), svc))
+	replayCtrl := controller.NewReplayController(service.NewReplayService(repository.NewReplayRepo(db), svc))
+	auditCtrl := controller.NewAuditController(auditSvc)
+	apiKeySvc := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), auditSvc, cfg)
+	apiKeyCtrl := controller.NewAPIKeyController(apiKeySvc)
+	configCtrl := controller.NewConfigController(service.NewConfigService(cfg, auditSvc))
+	credentialCtrl := controller.NewProviderCredentialController(
+		service.NewProviderCredentialService(repository.NewProviderCredentialRepo(db), auditSvc, providerSvc, cfg))
+	providerCtrl := controller.NewProviderController(providerSvc, svc)
+
+	var jwtVerifier *service.JWTVerifier
+	if cfg.Auth.JWT.Enabled {
+		var err error
+		if jwtVerifier, err = service.NewJWTVerifier(cfg.Auth.JWT); err != nil {
+			panic(fmt.Sprintf("invalid jwt auth config: %v", err))
+		}
+	}
+	auth := middleware.NewAuth(apiKeySvc, jwtVerifier, cfg)
+	// Handlers pass *gin.Context to services as context.Context; let it
+	// see the tenant the auth middleware puts on the request context.
+	r.ContextWithFallback = true
+	r.Use(middleware.RequestInfo())
+	read := auth.Require(models.ScopePromptsRead)
+	write := auth.Require(models.ScopePromptsWrite)
+	send := auth.Require(models.ScopeSend)
+	admin := auth.Require(models.ScopeAdmin)
+	// Provider settings, configuration reloads and re-encryption span every
+	// tenant, so only the platform key may use them.
+	global := middleware.RequireGlobal()
 
 	tmpl := template.Must(template.ParseFiles("templates/index.html"))
 	r.SetHTMLTemplate(tmpl)
@@ -29,11 +70,90 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 
 	api := r.Group("/ai/api/system-prompts")
 	{
-		api.POST("/", ctrl.Create)
-		api.GET("/", ctrl.Get)
-		api.PUT("/:id", ctrl.Update)
-		api.DELETE("/:id", ctrl.Delete)
-		api.POST("/send", ctrl.Send)
+		api.POST("/", write, ctrl.Create)
+		api.GET("/", read, ctrl.Get)
+		api.GET("/export", admin, ctrl.Export)
+		api.POST("/import", admin, ctrl.Import)
+		api.GET("/:id", read, ctrl.GetByID)
+		api.PUT("/:id", write, ctrl.Update)
+		api.DELETE("/:id", write, ctrl.Delete)
+		api.POST("/:id/restore", write, ctrl.Restore)
+		api.GET("/:id/versions", read, ctrl.ListVersions)
+		api.GET("/:id/versions/diff", read, ctrl.DiffVersions)
+		api.POST("/:id/rollback", write, ctrl.Rollback)
+		api.GET("/:id/labels", read, ctrl.ListLabels)
+		api.PUT("/:id/labels/:label", write, ctrl.SetLabel)
+		api.POST("/send", send, ctrl.Send)
+	}
+
+	experiments := r.Group("/ai/api/experiments")
+	{
+		experiments.POST("/", write, experimentCtrl.Create)
+		experiments.GET("/", read, experimentCtrl.List)
+		experiments.GET("/:id", read, experimentCtrl.Get)
+		experiments.POST("/:id/start", write, experimentCtrl.Start)
+		experiments.POST("/:id/stop", write, experimentCtrl.Stop)
+		experiments.GET("/:id/results", read, experimentCtrl.Results)
+	}
+
+	evals := r.Group("/ai/api/evals")
+	{
+		evals.POST("/datasets", write, evalCtrl.CreateDataset)
+		evals.GET("/datasets", read, evalCtrl.ListDatasets)
+		evals.GET("/datasets/:id", read, evalCtrl.GetDataset)
+		evals.POST("/datasets/:id/cases", write, evalCtrl.AddCases)
+		evals.POST("/runs", write, evalCtrl.StartRun)
+		evals.GET("/runs", read, evalCtrl.ListRuns)
+		evals.GET("/runs/:id", read, evalCtrl.GetRun)
+	}
+
+	replays := r.Group("/ai/api/replays")
+	{
+		replays.POST("/", write, replayCtrl.Create)
+		replays.GET("/", read, replayCtrl.List)
+		replays.GET("/:id/report", read, replayCtrl.Report)
+	}
+
+	usage := r.Group("/ai/api/usage")
+	{
+		usage.GET("/report", read, usageCtrl.Report)
+		usage.POST("/:id/feedback", send, usageCtrl.AddFeedback)
+		usage.GET("/:id/feedback", read, usageCtrl.ListFeedback)
+	}
+
+	adminAPI := r.Group("/ai/api/admin", admin)
+	{
+		adminAPI.POST("/system-prompts/:id/promote", ctrl.Promote)
+		adminAPI.POST("/api-keys", apiKeyCtrl.Issue)
+		adminAPI.GET("/api-keys", apiKeyCtrl.List)
+		adminAPI.POST("/api-keys/:id/rotate", apiKeyCtrl.Rotate)
+		adminAPI.DELETE("/api-keys/:id", apiKeyCtrl.Revoke)
+		adminAPI.PUT("/credentials", credentialCtrl.Set)
+		adminAPI.GET("/credentials", credentialCtrl.List)
+		adminAPI.DELETE("/credentials/:id", credentialCtrl.Delete)
+		adminAPI.POST("/credentials/reencrypt", global, credentialCtrl.Reencrypt)
+		adminAPI.POST("/reload", global, configCtrl.Reload)
+		adminAPI.GET("/providers", global, providerCtrl.List)
+		adminAPI.GET("/providers/:name", global, providerCtrl.Get)
+		adminAPI.PUT("/providers/:name", global, providerCtrl.SaveProvider)
+		adminAPI.DELETE("/providers/:name", global, providerCtrl.DeleteProvider)
+		adminAPI.PUT("/providers/:name/models/:model", global, providerCtrl.SaveModel)
+		adminAPI.DELETE("/providers/:name/models/:model", global, providerCtrl.DeleteModel)
+		adminAPI.POST("/providers/:name/models/:model/test", global, providerCtrl.TestModel)
+	}
+
+	r.GET("/ai/api/audit", admin, auditCtrl.List)
+
+	r.POST("/ai/api/embeddings", send, embeddingCtrl.Create)
+
+	collections := r.Group("/ai/api/collections")
+	{
+		collections.POST("/", write, docCtrl.CreateCollection)
+		collections.GET("/", read, docCtrl.ListCollections)
+		collections.DELETE("/:id", write, docCtrl.DeleteCollection)
+		collections.POST("/:id/documents", write, docCtrl.AddDocument)
+		collections.GET("/:id/documents", read, docCtrl.ListDocuments)
+		collections.POST("/:id/search", send, docCtrl.Search)
 	}
 
 }
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+)
+
+var (
+	// ErrInvalidAPIKey wraps validation failures when issuing a key.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrUnauthenticated is returned for missing, unknown, expired or
+	// revoked credentials.
+	ErrUnauthenticated = errors.New("unauthenticated")
+)
+
+const (
+	apiKeyPrefix = "aik"
+	// lastUsedResolution limits last-used writes to one per key per minute.
+	lastUsedResolution = time.Minute
+)
+
+// Principal is the authenticated caller of a request. An empty Module
+// means the caller may act on every module of its tenant; an empty
+// TenantID marks the bootstrap key, which may pick any tenant.
+type Principal struct {
+	KeyID    string
+	Name     string
+	TenantID string
+	Module   string
+	Scopes   []string
+}
+
+// HasScope reports whether the principal was granted scope, directly or
+// through admin.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessModule reports whether the principal may act on module.
+func (p *Principal) CanAccessModule(module string) bool {
+	return p.Module == "" || p.Module == module
+}
+
+type APIKeyService struct {
+	repo  *repository.APIKeyRepo
+	audit *AuditService
+	cfg   *config.Config
+}
+
+func NewAPIKeyService(repo *repository.APIKeyRepo, audit *AuditService, cfg *config.Config) *APIKeyService {
+	return &APIKeyService{repo: repo, audit: audit, cfg: cfg}
+}
+
+// IssuedKey carries the plaintext key, which is shown only once.
+type IssuedKey struct {
+	Key    *models.APIKey `json:"key"`
+	Secret string         `json:"secret"`
+}
+
+// Issue creates a key in the tenant of ctx.
+func (s *APIKeyService) Issue(ctx context.Context, name, module string, scopes []string, expiresAt *time.Time) (*IssuedKey, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	for _, scope := range scopes {
+		if !models.StringList(models.AllScopes).Contains(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry is in the past", ErrInvalidAPIKey)
+	}
+
+	key := &models.APIKey{Name: name, ModuleName: module, Scopes: scopes, ExpiresAt: expiresAt}
+	secret, err := newKeySecret(key)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.repo.Create(txCtx, key); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionCreate, models.AuditResourceAPIKey, key.ID.String(), nil, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &IssuedKey{Key: key, Secret: secret}, nil
+}
+
+func (s *APIKeyService) Get(ctx context.Context, id string) (*models.APIKey, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *APIKeyService) List(ctx context.Context, module string) ([]models.APIKey, error) {
+	return s.repo.List(ctx, module)
+}
+
+// Rotate replaces the key's secret; the old one stops working at once.
+func (s *APIKeyService) Rotate(ctx context.Context, id string) (*IssuedKey, error) {
+	var issued *IssuedKey
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		key, err := s.repo.Get(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if key.RevokedAt != nil {
+			return fmt.Errorf("%w: key is revoked", ErrInvalidAPIKey)
+		}
+		before := snapshot(key)
+		secret, err := newKeySecret(key)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Save(txCtx, key); err != nil {
+			return err
+		}
+		issued = &IssuedKey{Key: key, Secret: secret}
+		return s.audit.Record(txCtx, models.AuditActionUpdate, models.AuditResourceAPIKey, id, before, key)
+	})
+	return issued, err
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id string) error {
+	return s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		key, err := s.repo.Get(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if key.RevokedAt != nil {
+			return nil
+		}
+		before := snapshot(key)
+		now := time.Now()
+		key.RevokedAt = &now
+		if err := s.repo.Save(txCtx, key); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionDelete, models.AuditResourceAPIKey, id, before, key)
+	})
+}
+
+// Authenticate resolves a presented key to its principal. The configured
+// bootstrap key is accepted as a global admin so the first keys can be
+// issued.
+func (s *APIKeyService) Authenticate(ctx context.Context, presented string) (*Principal, error) {
+	if bootstrap := s.cfg.Auth.BootstrapKey; bootstrap != "" &&
+		subtle.ConstantTimeCompare([]byte(presented), []byte(bootstrap)) == 1 {
+		return &Principal{Name: "bootstrap", Scopes: []string{models.ScopeAdmin}}, nil
+	}
+
+	parts := strings.SplitN(presented, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, ErrUnauthenticated
+	}
+	key, err := s.repo.GetByPrefix(ctx, parts[1])
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(parts[2])), []byte(key.SecretHash)) != 1 {
+		return nil, ErrUnauthenticated
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil, ErrUnauthenticated
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(ctx, key.ID.String(), now); err != nil {
+			return nil, err
+		}
+	}
+	return &Principal{
+		KeyID:    key.ID.String(),
+		Name:     key.Name,
+		TenantID: key.TenantID,
+		Module:   key.ModuleName,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// newKeySecret sets a fresh prefix and secret hash on key and returns the
+// full key string, "aik_<prefix>_<secret>".
+func newKeySecret(key *models.APIKey) (string, error) {
+	prefix := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	key.Prefix = hex.EncodeToString(prefix)
+	key.SecretHash = hashSecret(encoded)
+	return apiKeyPrefix + "_" + key.Prefix + "_" + encoded, nil
+}
+
+// hashSecret is a plain SHA-256: the secrets are 256 random bits, so a slow
+// password hash would add latency to every request without adding safety.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
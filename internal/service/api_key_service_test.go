@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_IssueAuthenticateRotateRevoke(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := &config.Config{Auth: config.AuthConfig{Enabled: true, BootstrapKey: "bootstrap-secret"}}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	ctx := context.Background()
+
+	_, err := keys.Issue(ctx, "bad", "support", []string{"everything"}, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	issued, err := keys.Issue(ctx, "support bot", "support", []string{models.ScopeSend}, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, issued.Key.SecretHash, issued.Secret)
+
+	p, err := keys.Authenticate(ctx, issued.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, "support", p.Module)
+	assert.True(t, p.HasScope(models.ScopeSend))
+	assert.False(t, p.HasScope(models.ScopePromptsWrite))
+	assert.True(t, p.CanAccessModule("support"))
+	assert.False(t, p.CanAccessModule("billing"))
+
+	stored, err := keys.Get(ctx, issued.Key.ID.String())
+	require.NoError(t, err)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, err = keys.Authenticate(ctx, issued.Secret+"x")
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+
+	rotated, err := keys.Rotate(ctx, issued.Key.ID.String())
+	require.NoError(t, err)
+	_, err = keys.Authenticate(ctx, issued.Secret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated, "the old secret stops working")
+	_, err = keys.Authenticate(ctx, rotated.Secret)
+	require.NoError(t, err)
+
+	require.NoError(t, keys.Revoke(ctx, issued.Key.ID.String()))
+	_, err = keys.Authenticate(ctx, rotated.Secret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+
+	soon := time.Now().Add(50 * time.Millisecond)
+	expiring, err := keys.Issue(ctx, "temp", "", []string{models.ScopePromptsRead}, &soon)
+	require.NoError(t, err)
+	_, err = keys.Authenticate(ctx, expiring.Secret)
+	require.NoError(t, err)
+	time.Sleep(60 * time.Millisecond)
+	_, err = keys.Authenticate(ctx, expiring.Secret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+
+	root, err := keys.Authenticate(ctx, "bootstrap-secret")
+	require.NoError(t, err)
+	assert.True(t, root.HasScope(models.ScopePromptsWrite), "admin implies every scope")
+	assert.True(t, root.CanAccessModule("billing"))
+}
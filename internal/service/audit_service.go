@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditService struct {
+	repo *repository.AuditRepo
+}
+
+func NewAuditService(repo *repository.AuditRepo) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record writes an event for a change to a resource, attributed to the
+// actor of ctx. Call it inside the transaction making the change so the
+// two are kept or rolled back together. The before and after values are
+// stored as their JSON encoding; pass nil for the side that does not
+// exist. The event takes the module of the resource from them.
+func (s *AuditService) Record(ctx context.Context, action, resourceType, resourceID string, beforeValue, afterValue interface{}) error {
+	actor := audit.ActorFrom(ctx)
+	before, after := snapshot(beforeValue), snapshot(afterValue)
+	e := &models.AuditEvent{
+		ModuleName:   moduleOf(after, before),
+		ActorID:      actor.ID,
+		Actor:        actor.Name,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Before:       before,
+		After:        after,
+		IP:           actor.IP,
+		RequestID:    actor.RequestID,
+	}
+	if err := s.repo.Create(ctx, e); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// List returns the tenant's events matching f, newest first.
+func (s *AuditService) List(ctx context.Context, f repository.AuditFilter) ([]models.AuditEvent, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultAuditLimit
+	}
+	if f.Limit > maxAuditLimit {
+		f.Limit = maxAuditLimit
+	}
+	return s.repo.List(ctx, f)
+}
+
+// snapshot captures v as it is now, as its JSON encoding. Take it before
+// changing a resource to record its old state.
+func snapshot(v interface{}) models.JSONMap {
+	if m, ok := v.(models.JSONMap); ok || v == nil {
+		return m
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return models.JSONMap{"error": err.Error()}
+	}
+	var m models.JSONMap
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return models.JSONMap{"error": err.Error()}
+	}
+	return m
+}
+
+// moduleOf returns the ModuleName of the first snapshot that has one.
+func moduleOf(snapshots ...models.JSONMap) string {
+	for _, m := range snapshots {
+		if module, ok := m["ModuleName"].(string); ok {
+			return module
+		}
+	}
+	return ""
+}
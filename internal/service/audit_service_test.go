@@ -0,0 +1,82 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditService_RecordsAdministrativeChanges(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	auditSvc := service.NewAuditService(repository.NewAuditRepo(db))
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepo(db), auditSvc, cfg)
+	ctx := audit.WithActor(context.Background(),
+		audit.Actor{ID: "key-1", Name: "ops", IP: "10.0.0.7", RequestID: "req-1"})
+
+	sp, err := prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Be helpful."}, "", "")
+	require.NoError(t, err)
+	id := sp.ID.String()
+	_, err = prompts.Update(ctx, id, service.PromptFields{SystemPrompt: "Be brief."}, nil, "", "")
+	require.NoError(t, err)
+	_, err = prompts.SetLabel(ctx, id, "production", 2, "ops")
+	require.NoError(t, err)
+	require.NoError(t, prompts.Delete(ctx, id))
+
+	events, err := auditSvc.List(ctx, repository.AuditFilter{ResourceID: id})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	del, upd, create := events[0], events[1], events[2]
+
+	assert.Equal(t, models.AuditActionCreate, create.Action)
+	assert.Nil(t, create.Before)
+	assert.Equal(t, "Be helpful.", create.After["SystemPrompt"])
+	assert.Equal(t, "support", create.ModuleName)
+	assert.Equal(t, "ops", create.Actor)
+	assert.Equal(t, "key-1", create.ActorID)
+	assert.Equal(t, "10.0.0.7", create.IP)
+	assert.Equal(t, "req-1", create.RequestID)
+
+	assert.Equal(t, models.AuditActionUpdate, upd.Action)
+	assert.Equal(t, "Be helpful.", upd.Before["SystemPrompt"])
+	assert.Equal(t, "Be brief.", upd.After["SystemPrompt"])
+
+	assert.Equal(t, models.AuditActionDelete, del.Action)
+	assert.Equal(t, "Be brief.", del.Before["SystemPrompt"])
+	assert.Nil(t, del.After)
+
+	labels, err := auditSvc.List(ctx, repository.AuditFilter{ResourceType: models.AuditResourcePromptLabel})
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, id+"/production", labels[0].ResourceID)
+
+	// A failed change leaves no event behind.
+	_, err = prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Hi {{.name"}, "", "")
+	require.Error(t, err)
+	issued, err := keys.Issue(ctx, "bot", "billing", []string{models.ScopeSend}, nil)
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(ctx, issued.Key.ID.String()))
+
+	keyEvents, err := auditSvc.List(ctx, repository.AuditFilter{ResourceType: models.AuditResourceAPIKey})
+	require.NoError(t, err)
+	require.Len(t, keyEvents, 2)
+	assert.Equal(t, models.AuditActionDelete, keyEvents[0].Action)
+	assert.NotNil(t, keyEvents[0].After["RevokedAt"])
+	assert.NotContains(t, keyEvents[1].After, "SecretHash", "secret hashes stay out of the audit log")
+
+	billing, err := auditSvc.List(ctx, repository.AuditFilter{Module: "billing", Actor: "ops"})
+	require.NoError(t, err)
+	assert.Len(t, billing, 2)
+
+	all, err := auditSvc.List(ctx, repository.AuditFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 6)
+}
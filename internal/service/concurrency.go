@@ -0,0 +1,33 @@
+package service
+
+import "sync"
+
+// runConcurrently calls work on every item with at most n calls in flight
+// and hands each result to collect on the calling goroutine, so collect
+// can write to the database without further locking.
+func runConcurrently[T, R any](n int, items []T, work func(T) R, collect func(R)) {
+	in := make(chan T)
+	out := make(chan R)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				out <- work(item)
+			}
+		}()
+	}
+	go func() {
+		for _, item := range items {
+			in <- item
+		}
+		close(in)
+		wg.Wait()
+		close(out)
+	}()
+
+	for r := range out {
+		collect(r)
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+)
+
+// ConfigService reloads the provider and model configuration at runtime.
+type ConfigService struct {
+	cfg   *config.Config
+	audit *AuditService
+}
+
+func NewConfigService(cfg *config.Config, audit *AuditService) *ConfigService {
+	return &ConfigService{cfg: cfg, audit: audit}
+}
+
+// Reload swaps in the provider and model settings of the config file, if
+// they are valid, and audits what changed. Requests already running keep
+// the settings they started with.
+func (s *ConfigService) Reload(ctx context.Context) ([]string, error) {
+	changes, err := s.cfg.Reload()
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+	return changes, s.audit.Record(ctx, models.AuditActionUpdate, models.AuditResourceConfig, "defaults",
+		nil, map[string]interface{}{"changes": changes})
+}
@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reloadConfig = `
+security:
+  encryption_key: "0123456789abcdef0123456789abcdef"
+database:
+  host: localhost
+  user: test
+  name: test
+defaults:
+  provider: fake
+  model: MODEL
+  providers:
+    - name: fake
+      base_url: http://localhost
+      api_key: KEY
+      models:
+        - name: fake-model
+        - name: other-model
+`
+
+func writeReloadConfig(t *testing.T, dir, model, key string) {
+	t.Helper()
+	content := strings.NewReplacer("MODEL", model, "KEY", key).Replace(reloadConfig)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(content), 0o644))
+}
+
+func TestConfigService_ReloadsProviderSettings(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	dir := t.TempDir()
+	writeReloadConfig(t, dir, "fake-model", "first-key")
+	cfg, err := config.LoadConfig(dir)
+	require.NoError(t, err)
+	auditSvc := service.NewAuditService(repository.NewAuditRepo(db))
+	svc := service.NewConfigService(cfg, auditSvc)
+	ctx := context.Background()
+
+	var notified []string
+	cfg.OnReload(func(changes []string) { notified = changes })
+	before := cfg.CurrentDefaults()
+
+	writeReloadConfig(t, dir, "other-model", "second-key")
+	changes, err := svc.Reload(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default model: fake-model -> other-model", "provider fake: api_key changed"}, changes)
+	assert.Equal(t, changes, notified)
+	assert.Equal(t, "other-model", cfg.CurrentDefaults().Model)
+	assert.Equal(t, "second-key", cfg.CurrentDefaults().Providers[0].APIKey)
+	assert.Equal(t, "fake-model", before.Model, "earlier snapshots are left untouched")
+
+	changes, err = svc.Reload(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	writeReloadConfig(t, dir, "missing-model", "third-key")
+	_, err = svc.Reload(ctx)
+	assert.ErrorContains(t, err, "missing-model")
+	assert.Equal(t, "other-model", cfg.CurrentDefaults().Model, "an invalid config is not applied")
+
+	events, err := auditSvc.List(ctx, repository.AuditFilter{ResourceType: models.AuditResourceConfig})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.NotContains(t, events[0].After["changes"], "second-key")
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+)
+
+// RetrievalContextVar is the template variable a system prompt uses to place
+// retrieved chunks, e.g. "Answer using only this context: {{.context}}".
+const RetrievalContextVar = "context"
+
+type DocumentService struct {
+	repo       *repository.DocumentRepo
+	embeddings *EmbeddingService
+	cfg        *config.Config
+}
+
+func NewDocumentService(repo *repository.DocumentRepo, embeddings *EmbeddingService, cfg *config.Config) *DocumentService {
+	return &DocumentService{repo: repo, embeddings: embeddings, cfg: cfg}
+}
+
+func (s *DocumentService) CreateCollection(ctx context.Context, module, name, description string) (*models.DocumentCollection, error) {
+	c := &models.DocumentCollection{
+		ModuleName:  module,
+		Name:        name,
+		Description: description,
+	}
+	err := s.repo.CreateCollection(ctx, c)
+	return c, err
+}
+
+func (s *DocumentService) ListCollections(ctx context.Context, module string) ([]models.DocumentCollection, error) {
+	return s.repo.ListCollections(ctx, module)
+}
+
+func (s *DocumentService) GetCollection(ctx context.Context, id string) (*models.DocumentCollection, error) {
+	return s.repo.GetCollection(ctx, id)
+}
+
+func (s *DocumentService) DeleteCollection(ctx context.Context, id string) error {
+	return s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		return s.repo.DeleteCollection(txCtx, id)
+	})
+}
+
+func (s *DocumentService) ListDocuments(ctx context.Context, collectionID string) ([]models.Document, error) {
+	return s.repo.ListDocuments(ctx, collectionID)
+}
+
+// AddDocument chunks and embeds content, then stores the document and its
+// chunks in the collection.
+func (s *DocumentService) AddDocument(ctx context.Context, collectionID, title, content string) (*models.Document, error) {
+	collection, err := s.repo.GetCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := chunkText(content, s.cfg.Retrieval.ChunkSize, s.cfg.Retrieval.ChunkOverlap)
+	if len(texts) == 0 {
+		return nil, errors.New("document has no content")
+	}
+
+	embedded, err := s.embeddings.Embed(ctx, collection.ModuleName, texts, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed document: %w", err)
+	}
+
+	doc := &models.Document{
+		CollectionID: collection.ID,
+		Title:        title,
+		Content:      content,
+		ChunkCount:   len(texts),
+	}
+	chunks := make([]models.DocumentChunk, len(texts))
+	for i, text := range texts {
+		chunks[i] = models.DocumentChunk{
+			Position:  i,
+			Content:   text,
+			Embedding: embedded.Vectors[i],
+		}
+	}
+
+	err = s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		return s.repo.CreateDocument(txCtx, doc, chunks)
+	})
+	return doc, err
+}
+
+// Search returns the topK chunks of the module's named collection closest to query.
+func (s *DocumentService) Search(ctx context.Context, module, collectionName, query string, topK int) ([]models.DocumentChunk, error) {
+	collection, err := s.repo.GetCollectionByName(ctx, module, collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("collection %q not found for module %s: %w", collectionName, module, err)
+	}
+	return s.searchCollection(ctx, collection, query, topK)
+}
+
+func (s *DocumentService) SearchByID(ctx context.Context, collectionID, query string, topK int) ([]models.DocumentChunk, error) {
+	collection, err := s.repo.GetCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.searchCollection(ctx, collection, query, topK)
+}
+
+func (s *DocumentService) searchCollection(ctx context.Context, collection *models.DocumentCollection, query string, topK int) ([]models.DocumentChunk, error) {
+	if topK <= 0 {
+		topK = s.cfg.Retrieval.TopK
+	}
+	embedded, err := s.embeddings.Embed(ctx, collection.ModuleName, []string{query}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return s.repo.SearchChunks(ctx, collection.ID, embedded.Vectors[0], topK)
+}
+
+// RetrievalOptions selects the collection whose chunks are injected into the
+// system prompt for a /send request.
+type RetrievalOptions struct {
+	Collection string
+	TopK       int
+}
+
+// retrieve searches the collection with the user prompt and joins the
+// matching chunks into the text bound to {{.context}}.
+func (s *DocumentService) retrieve(ctx context.Context, module, user string, opts *RetrievalOptions) (string, error) {
+	chunks, err := s.Search(ctx, module, opts.Collection, user, opts.TopK)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, len(chunks))
+	for i, c := range chunks {
+		parts[i] = c.Content
+	}
+	return strings.Join(parts, "\n\n---\n\n"), nil
+}
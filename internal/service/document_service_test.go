@@ -0,0 +1,137 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// letterVector is a toy embedding: how often each letter a-z occurs.
+func letterVector(text string) []float32 {
+	v := make([]float32, 26)
+	for _, r := range strings.ToLower(text) {
+		if r >= 'a' && r <= 'z' {
+			v[r-'a']++
+		}
+	}
+	return v
+}
+
+// newFakeProvider serves OpenAI-style /embeddings and echoes completion
+// request bodies back as the generated text. Requests mentioning FAIL get
+// a 500 response, LLM-as-judge requests are graded 0.75 and moderation
+// requests are judged unsafe when they mention VIOLENT.
+func newFakeProvider(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			var data []map[string]interface{}
+			for i, in := range body["input"].([]interface{}) {
+				data = append(data, map[string]interface{}{"index": i, "embedding": letterVector(in.(string))})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+			return
+		}
+
+		echo, _ := json.Marshal(body)
+		if strings.Contains(string(echo), "FAIL") {
+			http.Error(w, "upstream failure", http.StatusInternalServerError)
+			return
+		}
+		text := string(echo)
+		if strings.Contains(text, "impartial evaluator") {
+			text = "0.75"
+		}
+		if strings.Contains(text, "content moderator") {
+			text = "SAFE"
+			if strings.Contains(string(echo), "VIOLENT") {
+				text = "UNSAFE: violence"
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"candidates": []interface{}{map[string]interface{}{
+				"content": map[string]interface{}{"parts": []interface{}{map[string]interface{}{"text": text}}},
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestConfig(baseURL string) *config.Config {
+	return &config.Config{
+		Defaults: config.DefaultConfig{
+			Provider: "openai",
+			Model:    "fake-model",
+			Providers: []config.ProviderConfig{{
+				Name:           "openai",
+				BaseURL:        baseURL + "/",
+				AuthMethod:     "header",
+				EmbeddingModel: "fake-embedding",
+				Models: []config.ModelConfig{{
+					Name:            "fake-model",
+					Config:          `{"system": {{printf "%q" .SystemPrompt}}, "user": {{printf "%q" .UserPrompt}}}`,
+					ResponsePath:    "candidates.0.content.parts.0.text",
+					InputCostPer1K:  0.5,
+					OutputCostPer1K: 1.5,
+				}},
+			}},
+		},
+		Retrieval: config.RetrievalConfig{ChunkSize: 60, ChunkOverlap: 10, TopK: 1},
+	}
+}
+
+func TestDocumentService_RetrievalAugmentedSend(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, &models.AIUsageLog{}, &models.EmbeddingCache{},
+		&models.DocumentCollection{}, &models.Document{}, &models.DocumentChunk{}, &models.ProviderCredential{}, &models.AuditEvent{},
+		&models.Provider{}, &models.Model{})
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	ctx := context.Background()
+
+	embeddings := service.NewEmbeddingService(db, cfg)
+	docs := service.NewDocumentService(repository.NewDocumentRepo(db), embeddings, cfg)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, docs)
+
+	collection, err := docs.CreateCollection(ctx, "support", "faq", "")
+	require.NoError(t, err)
+
+	doc, err := docs.AddDocument(ctx, collection.ID.String(), "cats",
+		"Cats purr when they are content. Cats also purr to soothe themselves when hurt.")
+	require.NoError(t, err)
+	assert.Equal(t, 2, doc.ChunkCount)
+
+	_, err = docs.AddDocument(ctx, collection.ID.String(), "rockets",
+		"Rockets burn fuel to launch satellites into orbit.")
+	require.NoError(t, err)
+
+	chunks, err := docs.Search(ctx, "support", "faq", "why do cats purr", 1)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Contains(t, chunks[0].Content, "Cats purr")
+
+	_, err = docs.Search(ctx, "billing", "faq", "why do cats purr", 1)
+	assert.Error(t, err, "collections are scoped to their module")
+
+	logEntry, err := prompts.SendPrompt(ctx, service.SendRequest{
+		Module:       "support",
+		SystemPrompt: "Answer from this context: {{.context}}",
+		UserPrompt:   "why do cats purr",
+		Retrieval:    &service.RetrievalOptions{Collection: "faq"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, logEntry.Response, "Answer from this context: Cats purr")
+	assert.NotContains(t, logEntry.Response, "Rockets")
+}
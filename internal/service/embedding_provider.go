@@ -0,0 +1,127 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+)
+
+// embeddingProvider turns a batch of texts into vectors using one upstream API.
+type embeddingProvider interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+func newEmbeddingProvider(provider *config.ProviderConfig) (embeddingProvider, error) {
+	if provider.EmbeddingModel == "" {
+		return nil, fmt.Errorf("provider %s has no embedding model configured", provider.Name)
+	}
+	switch strings.ToLower(provider.Name) {
+	case "gemini":
+		return &geminiEmbedder{provider: provider}, nil
+	case "openai":
+		return &openAIEmbedder{provider: provider}, nil
+	default:
+		return nil, fmt.Errorf("embeddings are not supported for provider %s", provider.Name)
+	}
+}
+
+// geminiEmbedder uses batchEmbedContents, the batched form of embedContent.
+type geminiEmbedder struct {
+	provider *config.ProviderConfig
+}
+
+func (g *geminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	type part struct {
+		Text string `json:"text"`
+	}
+	type content struct {
+		Parts []part `json:"parts"`
+	}
+	type request struct {
+		Model   string  `json:"model"`
+		Content content `json:"content"`
+	}
+
+	model := "models/" + g.provider.EmbeddingModel
+	reqs := make([]request, len(texts))
+	for i, text := range texts {
+		reqs[i] = request{Model: model, Content: content{Parts: []part{{Text: text}}}}
+	}
+	body, err := json.Marshal(map[string]interface{}{"requests": reqs})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s%s:batchEmbedContents", g.provider.BaseURL, g.provider.EmbeddingModel)
+	raw, err := postToProvider(ctx, g.provider, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, e := range resp.Embeddings {
+		vectors[i] = e.Values
+	}
+	return vectors, nil
+}
+
+// openAIEmbedder calls the OpenAI-compatible /embeddings endpoint.
+type openAIEmbedder struct {
+	provider *config.ProviderConfig
+}
+
+func (o *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": o.provider.EmbeddingModel,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(o.provider.BaseURL, "/") + "/embeddings"
+	raw, err := postToProvider(ctx, o.provider, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
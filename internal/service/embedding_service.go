@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"gorm.io/gorm"
+)
+
+type EmbeddingService struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	providers   *ProviderService
+	credentials *ProviderCredentialService
+}
+
+func NewEmbeddingService(db *gorm.DB, cfg *config.Config) *EmbeddingService {
+	audit := NewAuditService(repository.NewAuditRepo(db))
+	providers := NewProviderService(repository.NewProviderRepo(db), audit, cfg)
+	return &EmbeddingService{
+		db:          db,
+		cfg:         cfg,
+		providers:   providers,
+		credentials: NewProviderCredentialService(repository.NewProviderCredentialRepo(db), audit, providers, cfg),
+	}
+}
+
+type EmbeddingResult struct {
+	Provider string      `json:"provider"`
+	Model    string      `json:"model"`
+	Vectors  [][]float32 `json:"embeddings"`
+	Cached   []bool      `json:"cached"`
+}
+
+func hashContent(provider, model, moduleName, text string) string {
+	sum := sha256.Sum256([]byte(provider + model + moduleName + text))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *EmbeddingService) getEmbeddingProvider(ctx context.Context) (*config.ProviderConfig, error) {
+	defaults, err := s.providers.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if provider := findProvider(defaults, defaults.Provider); provider != nil {
+		return provider, nil
+	}
+	return nil, errors.New("configured provider not found")
+}
+
+// Embed returns one vector per text. Texts already embedded for this
+// module/provider/model are served from the cache; the rest are sent to the
+// provider in a single batch and logged as embedding usage.
+func (s *EmbeddingService) Embed(ctx context.Context, module string, texts []string, bypassCache bool) (*EmbeddingResult, error) {
+	provider, err := s.getEmbeddingProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if provider, err = s.credentials.Apply(ctx, module, provider); err != nil {
+		return nil, err
+	}
+	embedder, err := newEmbeddingProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EmbeddingResult{
+		Provider: provider.Name,
+		Model:    provider.EmbeddingModel,
+		Vectors:  make([][]float32, len(texts)),
+		Cached:   make([]bool, len(texts)),
+	}
+
+	hashes := make([]string, len(texts))
+	var missing []int
+	for i, text := range texts {
+		hashes[i] = hashContent(provider.Name, provider.EmbeddingModel, module, text)
+		if !bypassCache {
+			if cached, err := s.getCachedEmbedding(ctx, hashes[i]); err == nil {
+				result.Vectors[i] = cached.Vector
+				result.Cached[i] = true
+				continue
+			}
+		}
+		missing = append(missing, i)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	batch := make([]string, len(missing))
+	for j, i := range missing {
+		batch[j] = texts[i]
+	}
+	vectors, err := embedder.Embed(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for j, i := range missing {
+			result.Vectors[i] = vectors[j]
+			entry := &models.EmbeddingCache{
+				ModuleName:  module,
+				Provider:    provider.Name,
+				ModelName:   provider.EmbeddingModel,
+				ContentHash: hashes[i],
+				Vector:      vectors[j],
+			}
+			if err := tx.Create(entry).Error; err != nil {
+				return err
+			}
+		}
+
+		// Texts are embedded as given, but PII is kept out of the log.
+		redact, err := redactionFor(s.cfg.Redaction, module)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.AIUsageLog{
+			ModuleName: module,
+			Provider:   provider.Name,
+			Kind:       models.UsageKindEmbedding,
+			PromptHash: hashContent(provider.Name, provider.EmbeddingModel, module, strings.Join(batch, "\x00")),
+			Request:    redact.apply(strings.Join(batch, "\n")),
+			Response:   fmt.Sprintf("%d embeddings from %s", len(vectors), provider.EmbeddingModel),
+			Redactions: redact.entities(),
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store embeddings: %v", err)
+	}
+
+	return result, nil
+}
+
+func (s *EmbeddingService) getCachedEmbedding(ctx context.Context, hash string) (*models.EmbeddingCache, error) {
+	var entry models.EmbeddingCache
+	err := s.db.WithContext(ctx).Scopes(tenant.Scope(ctx)).
+		Where("content_hash = ?", hash).
+		Order("created_at DESC").
+		First(&entry).
+		Error
+
+	if err != nil {
+		return nil, fmt.Errorf("cache miss: %v", err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,183 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// embeddingServer records the texts of every batch it receives and answers
+// with respond.
+func embeddingServer(t *testing.T, respond func(w http.ResponseWriter, r *http.Request, texts []string)) (*httptest.Server, *[][]string) {
+	var batches [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input    []string `json:"input"`
+			Requests []struct {
+				Model   string `json:"model"`
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"requests"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		texts := body.Input
+		for _, req := range body.Requests {
+			assert.Equal(t, "models/fake-embedding", req.Model)
+			texts = append(texts, req.Content.Parts[0].Text)
+		}
+		batches = append(batches, texts)
+		respond(w, r, texts)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &batches
+}
+
+func embeddingConfig(provider, baseURL string) *config.Config {
+	cfg := newTestConfig(baseURL)
+	cfg.Defaults.Provider = provider
+	cfg.Defaults.Providers[0].Name = provider
+	return cfg
+}
+
+func embeddingLogs(t *testing.T, db *gorm.DB) int64 {
+	var n int64
+	require.NoError(t, db.Model(&models.AIUsageLog{}).Where("kind = ?", models.UsageKindEmbedding).Count(&n).Error)
+	return n
+}
+
+func TestEmbeddingService_BatchesMissesAndServesHitsFromCache(t *testing.T) {
+	srv, batches := embeddingServer(t, func(w http.ResponseWriter, r *http.Request, texts []string) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		var data []map[string]interface{}
+		for i, text := range texts {
+			data = append(data, map[string]interface{}{"index": i, "embedding": []float32{float32(len(text)), float32(i)}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	svc := service.NewEmbeddingService(db, embeddingConfig("openai", srv.URL))
+	ctx := context.Background()
+
+	res, err := svc.Embed(ctx, "support", []string{"a", "bb"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "bb"}}, *batches, "misses go out in one batch")
+	assert.Equal(t, [][]float32{{1, 0}, {2, 1}}, res.Vectors)
+	assert.Equal(t, []bool{false, false}, res.Cached)
+	assert.Equal(t, "fake-embedding", res.Model)
+	assert.Equal(t, int64(1), embeddingLogs(t, db))
+
+	res, err = svc.Embed(ctx, "support", []string{"a", "ccc"}, false)
+	require.NoError(t, err)
+	require.Len(t, *batches, 2)
+	assert.Equal(t, []string{"ccc"}, (*batches)[1], "only misses are sent")
+	assert.Equal(t, [][]float32{{1, 0}, {3, 0}}, res.Vectors)
+	assert.Equal(t, []bool{true, false}, res.Cached)
+	assert.Equal(t, int64(2), embeddingLogs(t, db))
+
+	res, err = svc.Embed(ctx, "support", []string{"bb", "ccc"}, false)
+	require.NoError(t, err)
+	assert.Len(t, *batches, 2, "all hits make no request")
+	assert.Equal(t, []bool{true, true}, res.Cached)
+	assert.Equal(t, int64(2), embeddingLogs(t, db), "cache hits are not logged")
+
+	// The cache is per module, and bypassing it sends everything.
+	res, err = svc.Embed(ctx, "billing", []string{"a"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, res.Cached)
+	res, err = svc.Embed(ctx, "support", []string{"a", "bb"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "bb"}, (*batches)[len(*batches)-1])
+	assert.Equal(t, []bool{false, false}, res.Cached)
+	assert.Equal(t, int64(4), embeddingLogs(t, db))
+
+	var entry models.AIUsageLog
+	require.NoError(t, db.Where("kind = ?", models.UsageKindEmbedding).Order("used_at").First(&entry).Error)
+	assert.Equal(t, "support", entry.ModuleName)
+	assert.Equal(t, "openai", entry.Provider)
+	assert.Equal(t, "a\nbb", entry.Request)
+	assert.Equal(t, "2 embeddings from fake-embedding", entry.Response)
+}
+
+func TestEmbeddingService_OpenAIResponses(t *testing.T) {
+	var reply string
+	srv, _ := embeddingServer(t, func(w http.ResponseWriter, r *http.Request, texts []string) {
+		if reply == "" {
+			http.Error(w, "upstream failure", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(reply))
+	})
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	svc := service.NewEmbeddingService(db, embeddingConfig("openai", srv.URL))
+	ctx := context.Background()
+
+	// Vectors are placed by their index, not by their position.
+	reply = `{"data": [{"index": 1, "embedding": [2]}, {"index": 0, "embedding": [1]}]}`
+	res, err := svc.Embed(ctx, "support", []string{"x", "y"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, res.Vectors)
+
+	for name, body := range map[string]string{
+		"missing":      `{"data": [{"index": 0, "embedding": [1]}]}`,
+		"out of range": `{"data": [{"index": 0, "embedding": [1]}, {"index": 2, "embedding": [2]}]}`,
+		"invalid":      `not json`,
+		"status":       ``,
+	} {
+		reply = body
+		_, err := svc.Embed(ctx, "support", []string{"x", "y"}, true)
+		assert.Error(t, err, name)
+	}
+	assert.Equal(t, int64(1), embeddingLogs(t, db), "failed batches are not logged")
+}
+
+func TestEmbeddingService_GeminiBatchEmbedContents(t *testing.T) {
+	dropped := 0
+	srv, batches := embeddingServer(t, func(w http.ResponseWriter, r *http.Request, texts []string) {
+		assert.True(t, strings.HasSuffix(r.URL.Path, "/fake-embedding:batchEmbedContents"), r.URL.Path)
+		n := len(texts) - dropped
+		var embeddings []map[string]interface{}
+		for i := 0; i < n; i++ {
+			embeddings = append(embeddings, map[string]interface{}{"values": []float32{float32(i + 1)}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	})
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	svc := service.NewEmbeddingService(db, embeddingConfig("gemini", srv.URL))
+	ctx := context.Background()
+
+	res, err := svc.Embed(ctx, "support", []string{"x", "y"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"x", "y"}}, *batches)
+	assert.Equal(t, [][]float32{{1}, {2}}, res.Vectors)
+	assert.Equal(t, "gemini", res.Provider)
+
+	dropped = 1
+	_, err = svc.Embed(ctx, "support", []string{"x", "y"}, true)
+	assert.ErrorContains(t, err, "expected 2 embeddings, got 1")
+}
+
+func TestEmbeddingService_UnsupportedProviders(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := embeddingConfig("anthropic", "http://unused.invalid")
+	_, err := service.NewEmbeddingService(db, cfg).Embed(context.Background(), "support", []string{"x"}, false)
+	assert.ErrorContains(t, err, "not supported")
+
+	cfg = embeddingConfig("openai", "http://unused.invalid")
+	cfg.Defaults.Providers[0].EmbeddingModel = ""
+	_, err = service.NewEmbeddingService(db, cfg).Embed(context.Background(), "support", []string{"x"}, false)
+	assert.ErrorContains(t, err, "no embedding model")
+}
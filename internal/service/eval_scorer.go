@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+)
+
+// scorer grades one output between 0 (fail) and 1 (pass).
+type scorer interface {
+	score(ctx context.Context, c *models.EvalCase, output string) (float64, error)
+}
+
+// newScorer validates cfg and builds its scorer. Judge calls go through
+// prompts so they are logged and costed like any other request of module.
+func newScorer(ctx context.Context, cfg models.ScorerConfig, prompts *SystemPromptService, module string) (scorer, error) {
+	switch cfg.Type {
+	case models.ScorerExactMatch:
+		return exactMatchScorer{}, nil
+	case models.ScorerRegex:
+		s := regexScorer{}
+		if cfg.Pattern != "" {
+			re, err := regexp.Compile(cfg.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: regex scorer: %v", ErrInvalidEval, err)
+			}
+			s.re = re
+		}
+		return s, nil
+	case models.ScorerJSONSchema:
+		var schema map[string]interface{}
+		if err := json.Unmarshal(cfg.Schema, &schema); err != nil {
+			return nil, fmt.Errorf("%w: json_schema scorer needs a schema object", ErrInvalidEval)
+		}
+		return jsonSchemaScorer{schema}, nil
+	case models.ScorerContainsKeywords:
+		return keywordsScorer{cfg.Keywords}, nil
+	case models.ScorerLLMJudge:
+		if (cfg.Provider == "") != (cfg.ModelName == "") {
+			return nil, fmt.Errorf("%w: llm_judge needs both provider and model_name, or neither", ErrInvalidEval)
+		}
+		if cfg.Provider != "" {
+			if _, _, err := prompts.findProviderAndModel(ctx, cfg.Provider, cfg.ModelName); err != nil {
+				return nil, fmt.Errorf("%w: llm_judge: %v", ErrInvalidEval, err)
+			}
+		}
+		return &judgeScorer{cfg: cfg, prompts: prompts, module: module}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown scorer %q", ErrInvalidEval, cfg.Type)
+	}
+}
+
+func boolScore(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+type exactMatchScorer struct{}
+
+func (exactMatchScorer) score(_ context.Context, c *models.EvalCase, output string) (float64, error) {
+	return boolScore(strings.TrimSpace(output) == strings.TrimSpace(c.Expected)), nil
+}
+
+// regexScorer matches its pattern, or the case's Expected when it has none.
+type regexScorer struct {
+	re *regexp.Regexp
+}
+
+func (s regexScorer) score(_ context.Context, c *models.EvalCase, output string) (float64, error) {
+	re := s.re
+	if re == nil {
+		if c.Expected == "" {
+			return 0, fmt.Errorf("no pattern configured")
+		}
+		var err error
+		if re, err = regexp.Compile(c.Expected); err != nil {
+			return 0, fmt.Errorf("case pattern: %w", err)
+		}
+	}
+	return boolScore(re.MatchString(output)), nil
+}
+
+type jsonSchemaScorer struct {
+	schema map[string]interface{}
+}
+
+func (s jsonSchemaScorer) score(_ context.Context, _ *models.EvalCase, output string) (float64, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(stripCodeFence(output)), &v); err != nil {
+		return 0, nil
+	}
+	return boolScore(validateJSONSchema(s.schema, v, "") == nil), nil
+}
+
+// stripCodeFence removes a surrounding ```json fence models like to add.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+// keywordsScorer scores the fraction of keywords found, case-insensitively.
+type keywordsScorer struct {
+	keywords []string
+}
+
+func (s keywordsScorer) score(_ context.Context, c *models.EvalCase, output string) (float64, error) {
+	keywords := append(append([]string{}, s.keywords...), c.Keywords...)
+	if len(keywords) == 0 {
+		return 0, fmt.Errorf("no keywords configured")
+	}
+	lower := strings.ToLower(output)
+	found := 0
+	for _, k := range keywords {
+		if strings.Contains(lower, strings.ToLower(k)) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(keywords)), nil
+}
+
+const judgeSystemPrompt = `You are an impartial evaluator grading an AI assistant's answer.
+Compare the answer with the expected answer and the rubric, if any.
+Reply with a single number between 0 and 1, where 1 is a perfect answer.`
+
+var judgeScorePattern = regexp.MustCompile(`\d*\.?\d+`)
+
+type judgeScorer struct {
+	cfg     models.ScorerConfig
+	prompts *SystemPromptService
+	module  string
+}
+
+func (s *judgeScorer) score(ctx context.Context, c *models.EvalCase, output string) (float64, error) {
+	var user strings.Builder
+	fmt.Fprintf(&user, "Question:\n%s\n\nAnswer:\n%s\n", c.Input, output)
+	if c.Expected != "" {
+		fmt.Fprintf(&user, "\nExpected answer:\n%s\n", c.Expected)
+	}
+	if s.cfg.Rubric != "" {
+		fmt.Fprintf(&user, "\nRubric:\n%s\n", s.cfg.Rubric)
+	}
+
+	verdict, err := s.prompts.SendPrompt(ctx, SendRequest{
+		Module:       s.module,
+		SystemPrompt: judgeSystemPrompt,
+		UserPrompt:   user.String(),
+		Provider:     s.cfg.Provider,
+		ModelName:    s.cfg.ModelName,
+		Source:       models.UsageSourceEval,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("judge: %w", err)
+	}
+
+	m := judgeScorePattern.FindString(verdict.Response)
+	if m == "" {
+		return 0, fmt.Errorf("judge returned no score: %q", verdict.Response)
+	}
+	score, err := strconv.ParseFloat(m, 64)
+	if err != nil || score < 0 || score > 1 {
+		return 0, fmt.Errorf("judge returned an invalid score: %q", verdict.Response)
+	}
+	return score, nil
+}
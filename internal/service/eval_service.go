@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+)
+
+// ErrInvalidEval wraps validation failures of datasets and runs.
+var ErrInvalidEval = errors.New("invalid evaluation")
+
+const (
+	defaultEvalConcurrency = 4
+	maxEvalConcurrency     = 32
+)
+
+type EvalService struct {
+	repo    *repository.EvalRepo
+	prompts *SystemPromptService
+}
+
+func NewEvalService(repo *repository.EvalRepo, prompts *SystemPromptService) *EvalService {
+	return &EvalService{repo: repo, prompts: prompts}
+}
+
+type EvalRunDetail struct {
+	Run     *models.EvalRun     `json:"run"`
+	Results []models.EvalResult `json:"results"`
+}
+
+func (s *EvalService) CreateDataset(ctx context.Context, d *models.EvalDataset) (*models.EvalDataset, error) {
+	if d.ModuleName == "" || d.Name == "" {
+		return nil, fmt.Errorf("%w: module and name are required", ErrInvalidEval)
+	}
+	if err := validateCases(d.Cases); err != nil {
+		return nil, err
+	}
+	err := s.repo.CreateDataset(ctx, d)
+	return d, err
+}
+
+func (s *EvalService) GetDataset(ctx context.Context, id string) (*models.EvalDataset, error) {
+	return s.repo.GetDataset(ctx, id)
+}
+
+func (s *EvalService) ListDatasets(ctx context.Context, module string) ([]models.EvalDataset, error) {
+	return s.repo.ListDatasets(ctx, module)
+}
+
+func (s *EvalService) AddCases(ctx context.Context, datasetID string, cases []models.EvalCase) ([]models.EvalCase, error) {
+	d, err := s.repo.GetDataset(ctx, datasetID)
+	if err != nil {
+		return nil, fmt.Errorf("dataset not found: %w", err)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("%w: no cases given", ErrInvalidEval)
+	}
+	if err := validateCases(cases); err != nil {
+		return nil, err
+	}
+	for i := range cases {
+		cases[i].DatasetID = d.ID
+	}
+	err = s.repo.AddCases(ctx, cases)
+	return cases, err
+}
+
+func validateCases(cases []models.EvalCase) error {
+	for i, c := range cases {
+		if strings.TrimSpace(c.Input) == "" {
+			return fmt.Errorf("%w: case %d has no input", ErrInvalidEval, i)
+		}
+	}
+	return nil
+}
+
+// CreateRun validates and stores a pending run. Execute runs it.
+func (s *EvalService) CreateRun(ctx context.Context, run *models.EvalRun) (*models.EvalRun, error) {
+	d, err := s.repo.GetDataset(ctx, run.DatasetID.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: dataset not found", ErrInvalidEval)
+	}
+	if len(d.Cases) == 0 {
+		return nil, fmt.Errorf("%w: dataset %s has no cases", ErrInvalidEval, d.Name)
+	}
+	version, err := s.prompts.repo.GetVersionByID(ctx, run.PromptVersionID.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: prompt version not found", ErrInvalidEval)
+	}
+	if version.ModuleName != d.ModuleName {
+		return nil, fmt.Errorf("%w: prompt version belongs to module %s, dataset to %s",
+			ErrInvalidEval, version.ModuleName, d.ModuleName)
+	}
+	if (run.Provider == "") != (run.ModelName == "") {
+		return nil, fmt.Errorf("%w: provider and model_name must be given together", ErrInvalidEval)
+	}
+	if run.Provider != "" {
+		if _, _, err := s.prompts.findProviderAndModel(ctx, run.Provider, run.ModelName); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEval, err)
+		}
+	}
+	if len(run.Scorers) == 0 {
+		return nil, fmt.Errorf("%w: at least one scorer is required", ErrInvalidEval)
+	}
+	seen := map[string]bool{}
+	for _, cfg := range run.Scorers {
+		if seen[cfg.Type] {
+			return nil, fmt.Errorf("%w: duplicate scorer %q", ErrInvalidEval, cfg.Type)
+		}
+		seen[cfg.Type] = true
+		if _, err := newScorer(ctx, cfg, s.prompts, d.ModuleName); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case run.Concurrency <= 0:
+		run.Concurrency = defaultEvalConcurrency
+	case run.Concurrency > maxEvalConcurrency:
+		run.Concurrency = maxEvalConcurrency
+	}
+	run.ModuleName = d.ModuleName
+	run.CaseCount = len(d.Cases)
+	run.Status = models.EvalRunPending
+	err = s.repo.CreateRun(ctx, run)
+	return run, err
+}
+
+// StartRun creates the run and executes it in the background; poll
+// GetRun for progress.
+func (s *EvalService) StartRun(ctx context.Context, run *models.EvalRun) (*models.EvalRun, error) {
+	created, err := s.CreateRun(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	go s.Execute(tenant.Detach(ctx), created.ID.String())
+	return created, nil
+}
+
+// Execute sends every case of the run's dataset through the run's prompt
+// version with up to Concurrency requests in flight, scores the outputs
+// and stores per-case results as they finish.
+func (s *EvalService) Execute(ctx context.Context, runID string) (*models.EvalRun, error) {
+	run, err := s.repo.GetRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != models.EvalRunPending {
+		return nil, fmt.Errorf("%w: run is %s", ErrInvalidEval, run.Status)
+	}
+	dataset, err := s.repo.GetDataset(ctx, run.DatasetID.String())
+	if err != nil {
+		return nil, s.failRun(ctx, run, err)
+	}
+	scorers := make([]scorer, len(run.Scorers))
+	for i, cfg := range run.Scorers {
+		if scorers[i], err = newScorer(ctx, cfg, s.prompts, run.ModuleName); err != nil {
+			return nil, s.failRun(ctx, run, err)
+		}
+	}
+
+	now := time.Now()
+	run.Status, run.StartedAt = models.EvalRunRunning, &now
+	if err := s.repo.SaveRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	cases := make([]*models.EvalCase, len(dataset.Cases))
+	for i := range dataset.Cases {
+		cases[i] = &dataset.Cases[i]
+	}
+
+	totals := map[string]float64{}
+	var storeErr error
+	runConcurrently(run.Concurrency, cases,
+		func(c *models.EvalCase) *models.EvalResult {
+			return s.evalCase(ctx, run, scorers, c)
+		},
+		func(result *models.EvalResult) {
+			if result.Error != "" {
+				run.FailedCases++
+			}
+			for name, score := range result.Scores {
+				totals[name] += score
+			}
+			if err := s.repo.CreateResult(ctx, result); err != nil && storeErr == nil {
+				storeErr = err
+			}
+		})
+	if storeErr != nil {
+		return nil, s.failRun(ctx, run, storeErr)
+	}
+
+	run.Scores = models.ScoreMap{}
+	for _, cfg := range run.Scorers {
+		run.Scores[cfg.Type] = totals[cfg.Type] / float64(len(dataset.Cases))
+	}
+	finished := time.Now()
+	run.Status, run.FinishedAt = models.EvalRunCompleted, &finished
+	if err := s.repo.SaveRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *EvalService) evalCase(ctx context.Context, run *models.EvalRun, scorers []scorer, c *models.EvalCase) *models.EvalResult {
+	result := &models.EvalResult{RunID: run.ID, CaseID: c.ID, Scores: models.ScoreMap{}}
+	for _, cfg := range run.Scorers {
+		result.Scores[cfg.Type] = 0
+	}
+
+	start := time.Now()
+	out, err := s.prompts.SendPrompt(ctx, SendRequest{
+		Module:      run.ModuleName,
+		UserPrompt:  c.Input,
+		Variables:   c.Variables,
+		VersionID:   run.PromptVersionID.String(),
+		Provider:    run.Provider,
+		ModelName:   run.ModelName,
+		BypassCache: true,
+		Source:      models.UsageSourceEval,
+	})
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Output = out.Response
+	result.UsageLogID = &out.ID
+
+	var errs []string
+	for i, sc := range scorers {
+		name := run.Scorers[i].Type
+		score, err := sc.score(ctx, c, out.Response)
+		if err != nil {
+			errs = append(errs, name+": "+err.Error())
+			continue
+		}
+		result.Scores[name] = score
+	}
+	result.Error = strings.Join(errs, "; ")
+	return result
+}
+
+func (s *EvalService) failRun(ctx context.Context, run *models.EvalRun, cause error) error {
+	finished := time.Now()
+	run.Status, run.Error, run.FinishedAt = models.EvalRunFailed, cause.Error(), &finished
+	if err := s.repo.SaveRun(ctx, run); err != nil {
+		return err
+	}
+	return cause
+}
+
+func (s *EvalService) GetRun(ctx context.Context, id string) (*EvalRunDetail, error) {
+	run, err := s.repo.GetRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.repo.ListResults(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &EvalRunDetail{Run: run, Results: results}, nil
+}
+
+// ListRuns returns the runs of module, or of every module when it is
+// empty, optionally limited to one dataset.
+func (s *EvalService) ListRuns(ctx context.Context, module, datasetID string) ([]models.EvalRun, error) {
+	return s.repo.ListRuns(ctx, module, datasetID)
+}
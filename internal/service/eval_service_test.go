@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalService_RunScoresEveryCase(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	evals := service.NewEvalService(repository.NewEvalRepo(db), prompts)
+	ctx := context.Background()
+
+	sp, err := prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Greet {{.name}}.", Variables: models.PromptVariables{{Name: "name", Type: models.VariableTypeString, Default: "friend"}}}, "", "")
+	require.NoError(t, err)
+
+	dataset, err := evals.CreateDataset(ctx, &models.EvalDataset{
+		ModuleName: "support",
+		Name:       "greetings",
+		Cases: []models.EvalCase{
+			{Input: "hello", Expected: "Greet Ada", Variables: models.JSONMap{"name": "Ada"}, Keywords: models.StringList{"Ada", "Grace"}},
+			{Input: "hi", Expected: `"user":"hi"`},
+			{Input: "FAIL"},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = evals.CreateRun(ctx, &models.EvalRun{
+		DatasetID:       dataset.ID,
+		PromptVersionID: *sp.CurrentVersionID,
+		Scorers:         models.ScorerConfigs{{Type: "bleu"}},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidEval)
+
+	run, err := evals.CreateRun(ctx, &models.EvalRun{
+		DatasetID:       dataset.ID,
+		PromptVersionID: *sp.CurrentVersionID,
+		Concurrency:     2,
+		Scorers: models.ScorerConfigs{
+			{Type: models.ScorerRegex},
+			{Type: models.ScorerContainsKeywords, Keywords: []string{"greet"}},
+			{Type: models.ScorerJSONSchema, Schema: json.RawMessage(
+				`{"type": "object", "required": ["system", "user"], "properties": {"user": {"type": "string"}}}`)},
+			{Type: models.ScorerLLMJudge},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.EvalRunPending, run.Status)
+
+	run, err = evals.Execute(ctx, run.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, models.EvalRunCompleted, run.Status)
+	assert.Equal(t, 3, run.CaseCount)
+	assert.Equal(t, 1, run.FailedCases)
+
+	detail, err := evals.GetRun(ctx, run.ID.String())
+	require.NoError(t, err)
+	require.Len(t, detail.Results, 3)
+
+	byInput := map[string]models.EvalResult{}
+	for _, c := range dataset.Cases {
+		for _, r := range detail.Results {
+			if r.CaseID == c.ID {
+				byInput[c.Input] = r
+			}
+		}
+	}
+
+	hello := byInput["hello"]
+	assert.Contains(t, hello.Output, "Greet Ada.")
+	assert.Empty(t, hello.Error)
+	assert.Equal(t, 1.0, hello.Scores[models.ScorerRegex])
+	assert.InDelta(t, 2.0/3, hello.Scores[models.ScorerContainsKeywords], 1e-9)
+	assert.Equal(t, 1.0, hello.Scores[models.ScorerJSONSchema])
+	assert.Equal(t, 0.75, hello.Scores[models.ScorerLLMJudge])
+	assert.NotNil(t, hello.UsageLogID)
+
+	hi := byInput["hi"]
+	assert.Equal(t, 1.0, hi.Scores[models.ScorerRegex])
+	assert.Equal(t, 1.0, hi.Scores[models.ScorerContainsKeywords])
+
+	failed := byInput["FAIL"]
+	assert.NotEmpty(t, failed.Error)
+	assert.Equal(t, 0.0, failed.Scores[models.ScorerLLMJudge])
+
+	assert.InDelta(t, 0.5, run.Scores[models.ScorerLLMJudge], 1e-9)
+
+	_, err = evals.Execute(ctx, run.ID.String())
+	assert.ErrorIs(t, err, service.ErrInvalidEval, "a finished run cannot be executed again")
+}
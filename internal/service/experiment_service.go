@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidExperiment wraps validation failures when creating or starting
+// an experiment.
+var ErrInvalidExperiment = errors.New("invalid experiment")
+
+type ExperimentService struct {
+	repo      *repository.ExperimentRepo
+	prompts   *repository.SystemPromptRepo
+	providers *ProviderService
+}
+
+func NewExperimentService(repo *repository.ExperimentRepo, prompts *repository.SystemPromptRepo, providers *ProviderService) *ExperimentService {
+	return &ExperimentService{repo: repo, prompts: prompts, providers: providers}
+}
+
+type VariantResult struct {
+	VariantID    string          `json:"variant_id"`
+	Name         string          `json:"name"`
+	Requests     int64           `json:"requests"`
+	Errors       int64           `json:"errors"`
+	ErrorRate    float64         `json:"error_rate"`
+	AvgLatencyMs float64         `json:"avg_latency_ms"`
+	TotalCost    float64         `json:"total_cost"`
+	AvgCost      float64         `json:"avg_cost"`
+	Feedback     FeedbackSummary `json:"feedback"`
+}
+
+type ExperimentResults struct {
+	Experiment *models.Experiment `json:"experiment"`
+	Variants   []VariantResult    `json:"variants"`
+}
+
+func (s *ExperimentService) Create(ctx context.Context, e *models.Experiment) (*models.Experiment, error) {
+	if len(e.Variants) == 0 {
+		return nil, fmt.Errorf("%w: at least one variant is required", ErrInvalidExperiment)
+	}
+	providers, err := s.providers.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, v := range e.Variants {
+		if v.Weight <= 0 {
+			return nil, fmt.Errorf("%w: variant %q needs a positive weight", ErrInvalidExperiment, v.Name)
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("%w: duplicate variant name %q", ErrInvalidExperiment, v.Name)
+		}
+		names[v.Name] = true
+
+		version, err := s.prompts.GetVersionByID(ctx, v.PromptVersionID.String())
+		if err != nil {
+			return nil, fmt.Errorf("%w: variant %q: prompt version not found", ErrInvalidExperiment, v.Name)
+		}
+		if version.ModuleName != e.ModuleName {
+			return nil, fmt.Errorf("%w: variant %q uses a prompt from module %s", ErrInvalidExperiment, v.Name, version.ModuleName)
+		}
+		if e.SystemPromptID == uuid.Nil {
+			e.SystemPromptID = version.SystemPromptID
+		}
+		if version.SystemPromptID != e.SystemPromptID {
+			return nil, fmt.Errorf("%w: variant %q uses a version of another prompt", ErrInvalidExperiment, v.Name)
+		}
+		if !hasModel(providers, v.Provider, v.ModelName) {
+			return nil, fmt.Errorf("%w: variant %q: model %s not configured for provider %s",
+				ErrInvalidExperiment, v.Name, v.ModelName, v.Provider)
+		}
+	}
+
+	e.Status = models.ExperimentDraft
+	err = s.repo.Create(ctx, e)
+	return e, err
+}
+
+func hasModel(d *config.DefaultConfig, providerName, modelName string) bool {
+	p := findProvider(d, providerName)
+	return p != nil && findModel(p, modelName) != nil
+}
+
+func (s *ExperimentService) Get(ctx context.Context, id string) (*models.Experiment, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *ExperimentService) List(ctx context.Context, module string) ([]models.Experiment, error) {
+	return s.repo.List(ctx, module)
+}
+
+// Start puts the experiment live. A module runs at most one experiment at
+// a time so assignment stays unambiguous; the status change is a single
+// conditional update so the unique index settles concurrent starts.
+func (s *ExperimentService) Start(ctx context.Context, id string) error {
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if e.Status == models.ExperimentRunning {
+		return nil
+	}
+	if e.SystemPromptID == uuid.Nil {
+		return fmt.Errorf("%w: experiment %q has no target prompt", ErrInvalidExperiment, e.Name)
+	}
+	ok, err := s.repo.SetStatus(ctx, id, models.ExperimentRunning, models.ExperimentDraft, models.ExperimentStopped)
+	if err != nil {
+		running, rerr := s.repo.GetRunning(ctx, e.ModuleName)
+		if rerr == nil && running.ID != e.ID {
+			return fmt.Errorf("%w: experiment %q is already running for module %s",
+				ErrInvalidExperiment, running.Name, e.ModuleName)
+		}
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: experiment %q changed status concurrently", ErrInvalidExperiment, e.Name)
+	}
+	return nil
+}
+
+// Stop ends a running experiment. Stopping one that is not running is an
+// error, so a stale client cannot silently overwrite a draft.
+func (s *ExperimentService) Stop(ctx context.Context, id string) error {
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.SetStatus(ctx, id, models.ExperimentStopped, models.ExperimentRunning)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: experiment %q is not running", ErrInvalidExperiment, e.Name)
+	}
+	return nil
+}
+
+// Results reports latency, cost, error rate and feedback per variant.
+func (s *ExperimentService) Results(ctx context.Context, id string) (*ExperimentResults, error) {
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.repo.VariantStats(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	byVariant := make(map[string]repository.VariantStats, len(stats))
+	for _, st := range stats {
+		byVariant[st.VariantID] = st
+	}
+	feedback, err := s.repo.VariantFeedback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	feedbackByVariant := make(map[string]repository.FeedbackStats, len(feedback))
+	for _, f := range feedback {
+		feedbackByVariant[f.VariantID] = f.FeedbackStats
+	}
+
+	results := &ExperimentResults{Experiment: e}
+	for _, v := range e.Variants {
+		st := byVariant[v.ID.String()]
+		r := VariantResult{
+			VariantID:    v.ID.String(),
+			Name:         v.Name,
+			Requests:     st.Requests,
+			Errors:       st.Errors,
+			AvgLatencyMs: st.AvgLatencyMs,
+			TotalCost:    st.TotalCost,
+			Feedback:     summarizeFeedback(feedbackByVariant[v.ID.String()]),
+		}
+		if st.Requests > 0 {
+			r.ErrorRate = float64(st.Errors) / float64(st.Requests)
+			r.AvgCost = st.TotalCost / float64(st.Requests)
+		}
+		results.Variants = append(results.Variants, r)
+	}
+	return results, nil
+}
+
+// pickVariant deterministically maps a subject to a variant: the same
+// subject always lands in the same arm for a given experiment.
+func pickVariant(e *models.Experiment, subjectID string) *models.ExperimentVariant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(e.ID.String() + ":" + subjectID))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+	for i := range e.Variants {
+		bucket -= e.Variants[i].Weight
+		if bucket < 0 {
+			return &e.Variants[i]
+		}
+	}
+	return nil
+}
+
+// assignVariant returns the subject's variant in the module's running
+// experiment, or nil when none is running or the experiment targets
+// another prompt than promptID.
+func (s *SystemPromptService) assignVariant(ctx context.Context, module, promptID, subjectID string) (*models.ExperimentVariant, error) {
+	e, err := s.experiments.GetRunning(ctx, module)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.SystemPromptID.String() != promptID {
+		return nil, nil
+	}
+	return pickVariant(e, subjectID), nil
+}
@@ -0,0 +1,159 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperimentService_StickyAssignmentAndResults(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	promptRepo := repository.NewSystemPromptRepo(db)
+	prompts := service.NewSystemPromptService(db, promptRepo, cfg, nil)
+	experiments := service.NewExperimentService(repository.NewExperimentRepo(db), promptRepo,
+		service.NewProviderService(repository.NewProviderRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg))
+	ctx := context.Background()
+
+	sp, err := prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Control prompt."}, "", "")
+	require.NoError(t, err)
+	_, err = prompts.Update(ctx, sp.ID.String(), service.PromptFields{SystemPrompt: "Treatment prompt FAIL."}, nil, "", "")
+	require.NoError(t, err)
+	versions, err := prompts.ListVersions(ctx, sp.ID.String())
+	require.NoError(t, err)
+
+	exp, err := experiments.Create(ctx, &models.Experiment{
+		ModuleName: "support",
+		Name:       "tone",
+		Variants: []models.ExperimentVariant{
+			{Name: "control", Weight: 1, PromptVersionID: versions[1].ID, Provider: "openai", ModelName: "fake-model"},
+			{Name: "treatment", Weight: 1, PromptVersionID: versions[0].ID, Provider: "openai", ModelName: "fake-model"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, experiments.Start(ctx, exp.ID.String()))
+
+	assigned := map[string]string{}
+	for i := 0; i < 40; i++ {
+		subject := fmt.Sprintf("user-%d", i%20)
+		out, err := prompts.SendPrompt(ctx, service.SendRequest{
+			Module: "support", PromptID: sp.ID.String(), UserPrompt: "hi", SubjectID: subject, BypassCache: true,
+		})
+
+		arm := "control"
+		if err != nil {
+			arm = "treatment"
+		} else {
+			assert.Contains(t, out.Response, "Control prompt.")
+		}
+		if prev, ok := assigned[subject]; ok {
+			assert.Equal(t, prev, arm, "subject %s switched variants", subject)
+		}
+		assigned[subject] = arm
+	}
+
+	results, err := experiments.Results(ctx, exp.ID.String())
+	require.NoError(t, err)
+	require.Len(t, results.Variants, 2)
+
+	var total int64
+	for _, v := range results.Variants {
+		total += v.Requests
+		assert.NotZero(t, v.Requests, "variant %s got no traffic", v.Name)
+		if v.Name == "control" {
+			assert.Zero(t, v.ErrorRate)
+			assert.Greater(t, v.TotalCost, 0.0)
+		} else {
+			assert.Equal(t, 1.0, v.ErrorRate)
+		}
+	}
+	assert.Equal(t, int64(40), total)
+
+	// Requests by label are assigned too, to the label's prompt.
+	out, err := prompts.SendPrompt(ctx, service.SendRequest{
+		Module: "support", Label: models.LabelDraft, UserPrompt: "hi", SubjectID: "user-0", BypassCache: true,
+	})
+	if assigned["user-0"] == "control" {
+		require.NoError(t, err)
+		require.NotNil(t, out.ExperimentID)
+		assert.Equal(t, exp.ID, *out.ExperimentID)
+	} else {
+		assert.Error(t, err)
+	}
+
+	// Requests for other prompts of the module are left alone.
+	for i := 0; i < 20; i++ {
+		out, err := prompts.SendPrompt(ctx, service.SendRequest{
+			Module: "support", SystemPrompt: "Inline prompt.", UserPrompt: "hi",
+			SubjectID: fmt.Sprintf("user-%d", i), BypassCache: true,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, out.ExperimentID)
+	}
+
+	_, err = experiments.Create(ctx, &models.Experiment{
+		ModuleName: "billing",
+		Name:       "cross-module",
+		Variants: []models.ExperimentVariant{
+			{Name: "a", Weight: 1, PromptVersionID: versions[0].ID, Provider: "openai", ModelName: "fake-model"},
+		},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidExperiment)
+
+	other, err := prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Other prompt."}, "", "")
+	require.NoError(t, err)
+	_, err = experiments.Create(ctx, &models.Experiment{
+		ModuleName: "support",
+		Name:       "mixed",
+		Variants: []models.ExperimentVariant{
+			{Name: "a", Weight: 1, PromptVersionID: versions[0].ID, Provider: "openai", ModelName: "fake-model"},
+			{Name: "b", Weight: 1, PromptVersionID: *other.CurrentVersionID, Provider: "openai", ModelName: "fake-model"},
+		},
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidExperiment, "variants must share a prompt")
+}
+
+func TestExperimentService_OneRunningPerModule(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	promptRepo := repository.NewSystemPromptRepo(db)
+	prompts := service.NewSystemPromptService(db, promptRepo, cfg, nil)
+	repo := repository.NewExperimentRepo(db)
+	experiments := service.NewExperimentService(repo, promptRepo,
+		service.NewProviderService(repository.NewProviderRepo(db), service.NewAuditService(repository.NewAuditRepo(db)), cfg))
+	ctx := context.Background()
+
+	sp, err := prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Prompt."}, "", "")
+	require.NoError(t, err)
+	create := func(name string) string {
+		e, err := experiments.Create(ctx, &models.Experiment{
+			ModuleName: "support",
+			Name:       name,
+			Variants: []models.ExperimentVariant{
+				{Name: "a", Weight: 1, PromptVersionID: *sp.CurrentVersionID, Provider: "openai", ModelName: "fake-model"},
+			},
+		})
+		require.NoError(t, err)
+		return e.ID.String()
+	}
+	first, second := create("first"), create("second")
+
+	assert.ErrorIs(t, experiments.Stop(ctx, first), service.ErrInvalidExperiment, "drafts cannot be stopped")
+	require.NoError(t, experiments.Start(ctx, first))
+	assert.ErrorIs(t, experiments.Start(ctx, second), service.ErrInvalidExperiment)
+
+	// The index holds even when the check in Start is skipped.
+	_, err = repo.SetStatus(ctx, second, models.ExperimentRunning, models.ExperimentDraft)
+	assert.Error(t, err)
+
+	require.NoError(t, experiments.Stop(ctx, first))
+	assert.ErrorIs(t, experiments.Stop(ctx, first), service.ErrInvalidExperiment)
+	require.NoError(t, experiments.Start(ctx, second))
+}
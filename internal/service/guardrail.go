@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+)
+
+const (
+	GuardrailBlock   = "block"
+	GuardrailWarn    = "warn"
+	GuardrailRewrite = "rewrite"
+	GuardrailOff     = "off"
+
+	GuardrailStageInput  = "input"
+	GuardrailStageOutput = "output"
+
+	// GuardrailErrorCode is returned to API callers whose request or
+	// response was blocked.
+	GuardrailErrorCode = "guardrail_blocked"
+
+	// rewrittenSpan replaces text removed by a rewrite policy.
+	rewrittenSpan = "[removed]"
+	// withheldResponse replaces output the moderation model rejected.
+	withheldResponse = "[response withheld by content policy]"
+
+	moderationSystemPrompt = "You are a content moderator. Reply with SAFE if the text is acceptable. " +
+		"Otherwise reply with UNSAFE followed by the category, for example \"UNSAFE: violence\"."
+)
+
+// ErrGuardrailBlocked is wrapped by GuardrailError.
+var ErrGuardrailBlocked = errors.New("blocked by guardrail")
+
+// GuardrailError reports the rule that blocked a request or its response.
+type GuardrailError struct {
+	Stage  string `json:"stage"`
+	Rule   string `json:"rule"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (e *GuardrailError) Error() string {
+	msg := fmt.Sprintf("%v: %s rule %s", ErrGuardrailBlocked, e.Stage, e.Rule)
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return msg
+}
+
+func (e *GuardrailError) Unwrap() error { return ErrGuardrailBlocked }
+
+// Prompt injection heuristics: phrasing that tries to override or reveal
+// the system prompt.
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\b.{0,20}\b(previous|prior|above|earlier|all)\b.{0,20}\b(instructions?|prompts?|rules|directions)\b`),
+	regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|output)\b.{0,20}\b(system prompt|hidden prompt|initial instructions|your instructions)\b`),
+	regexp.MustCompile(`(?i)\byou are now\b.{0,30}\b(unfiltered|uncensored|jailbroken|DAN|developer mode)\b`),
+	regexp.MustCompile(`(?i)\b(pretend|act as if)\b.{0,30}\b(no|without)\b.{0,10}\b(rules|restrictions|guidelines)\b`),
+}
+
+// guardrail is a module's compiled policy.
+type guardrail struct {
+	module    string
+	policy    *config.GuardrailPolicy
+	blocklist *regexp.Regexp
+	rules     []namedPattern
+}
+
+type namedPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// guardrailFor returns the module's guardrail, or nil when none applies.
+// A nil guardrail passes everything.
+func guardrailFor(cfg config.GuardrailConfig, module string) (*guardrail, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	var policy *config.GuardrailPolicy
+	for i := range cfg.Policies {
+		p := &cfg.Policies[i]
+		if p.Module == module {
+			policy = p
+			break
+		}
+		if p.Module == "" && policy == nil {
+			policy = p
+		}
+	}
+	if policy == nil || policy.Action == GuardrailOff || policy.Action == "" {
+		return nil, nil
+	}
+
+	g := &guardrail{module: module, policy: policy}
+	if len(policy.Blocklist) > 0 {
+		terms := make([]string, len(policy.Blocklist))
+		for i, term := range policy.Blocklist {
+			terms[i] = regexp.QuoteMeta(term)
+		}
+		re, err := compilePattern(`(?i)\b(?:` + strings.Join(terms, "|") + `)\b`)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail blocklist: %w", err)
+		}
+		g.blocklist = re
+	}
+	for _, r := range policy.Rules {
+		re, err := compilePattern(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail rule %s: %w", r.Name, err)
+		}
+		g.rules = append(g.rules, namedPattern{r.Name, re})
+	}
+	return g, nil
+}
+
+// checkGuardrail applies the guardrail to one stage's text. It returns the text,
+// rewritten if the policy says so, and the rules that fired as
+// "<stage>:<rule>". Under a block policy the first rule that fires stops
+// the check with a *GuardrailError.
+func (s *SystemPromptService) checkGuardrail(ctx context.Context, g *guardrail, stage, text string) (string, []string, error) {
+	if g == nil {
+		return text, nil, nil
+	}
+	action := g.policy.Action
+	var hits []string
+	fire := func(rule, detail string) error {
+		hits = append(hits, stage+":"+rule)
+		if action == GuardrailBlock {
+			return &GuardrailError{Stage: stage, Rule: rule, Detail: detail}
+		}
+		return nil
+	}
+	// match fires rule when re matches, removing the matches when
+	// rewriting.
+	match := func(rule string, re *regexp.Regexp) error {
+		if !re.MatchString(text) {
+			return nil
+		}
+		if err := fire(rule, ""); err != nil {
+			return err
+		}
+		if action == GuardrailRewrite {
+			text = re.ReplaceAllString(text, rewrittenSpan)
+		}
+		return nil
+	}
+
+	if g.blocklist != nil {
+		if err := match("blocklist", g.blocklist); err != nil {
+			return text, hits, err
+		}
+	}
+	for _, r := range g.rules {
+		if err := match(r.name, r.re); err != nil {
+			return text, hits, err
+		}
+	}
+	if stage == GuardrailStageInput && g.policy.PromptInjection {
+		for _, re := range injectionPatterns {
+			if err := match("prompt_injection", re); err != nil {
+				return text, hits, err
+			}
+		}
+	}
+
+	limit := g.policy.MaxInputLength
+	if stage == GuardrailStageOutput {
+		limit = g.policy.MaxOutputLength
+	}
+	if runes := []rune(text); limit > 0 && len(runes) > limit {
+		if err := fire("max_length", fmt.Sprintf("%d > %d characters", len(runes), limit)); err != nil {
+			return text, hits, err
+		}
+		if action == GuardrailRewrite {
+			text = string(runes[:limit])
+		}
+	}
+
+	if g.policy.Moderation.Enabled {
+		category, err := s.moderate(ctx, g.module, g.policy.Moderation, text)
+		if err != nil {
+			return text, hits, err
+		}
+		if category != "" {
+			// Input cannot be rewritten into something meaningful, so a
+			// rewrite policy blocks it.
+			if action == GuardrailRewrite && stage == GuardrailStageInput {
+				return text, append(hits, stage+":moderation"),
+					&GuardrailError{Stage: stage, Rule: "moderation", Detail: category}
+			}
+			if err := fire("moderation", category); err != nil {
+				return text, hits, err
+			}
+			if action == GuardrailRewrite {
+				text = withheldResponse
+			}
+		}
+	}
+	return text, hits, nil
+}
+
+// moderate asks the moderation model about text and returns the category
+// it flagged, or "" when it considers the text safe.
+func (s *SystemPromptService) moderate(ctx context.Context, module string, mc config.ModerationConfig, text string) (string, error) {
+	provider, model, err := s.findProviderAndModel(ctx, mc.Provider, mc.Model)
+	if err != nil {
+		return "", fmt.Errorf("moderation: %w", err)
+	}
+	if provider, err = s.credentials.Apply(ctx, module, provider); err != nil {
+		return "", fmt.Errorf("moderation: %w", err)
+	}
+	out, err := s.callAIAPI(ctx, provider, model, moderationSystemPrompt, text)
+	if err != nil {
+		return "", fmt.Errorf("moderation: %w", err)
+	}
+
+	verdict := strings.TrimSpace(out.Text)
+	upper := strings.ToUpper(verdict)
+	switch {
+	case strings.HasPrefix(upper, "UNSAFE"):
+		category := strings.TrimLeft(verdict[len("UNSAFE"):], " :-")
+		if category == "" {
+			category = "unsafe"
+		}
+		return category, nil
+	case strings.HasPrefix(upper, "SAFE"):
+		return "", nil
+	}
+	return "", fmt.Errorf("moderation: unexpected verdict %q", verdict)
+}
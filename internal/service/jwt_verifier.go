@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+)
+
+// jwtLeeway tolerates clock skew between us and the identity provider.
+const jwtLeeway = 30 * time.Second
+
+// JWTVerifier authenticates bearer JWTs issued by an identity provider,
+// checking them against its JWKS or a static key and mapping their claims
+// to a Principal.
+type JWTVerifier struct {
+	cfg     config.JWTConfig
+	jwks    *jwksCache
+	static  crypto.PublicKey
+	hmacKey []byte
+}
+
+// NewJWTVerifier checks the configuration. StaticKey may be a PEM encoded
+// public key or, when it is not PEM, a shared HMAC secret.
+func NewJWTVerifier(cfg config.JWTConfig) (*JWTVerifier, error) {
+	if cfg.ModuleClaim == "" {
+		cfg.ModuleClaim = "module"
+	}
+	if cfg.ScopesClaim == "" {
+		cfg.ScopesClaim = "scope"
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant"
+	}
+	if cfg.JWKSRefresh <= 0 {
+		cfg.JWKSRefresh = time.Hour
+	}
+
+	v := &JWTVerifier{cfg: cfg}
+	switch {
+	case cfg.JWKSURL != "":
+		v.jwks = newJWKSCache(cfg.JWKSURL, cfg.JWKSRefresh)
+	case cfg.StaticKey != "":
+		if block, _ := pem.Decode([]byte(cfg.StaticKey)); block != nil {
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("jwt static key: %w", err)
+			}
+			v.static = pub
+		} else {
+			v.hmacKey = []byte(cfg.StaticKey)
+		}
+	default:
+		return nil, errors.New("jwt auth needs a jwks_url or a static_key")
+	}
+	return v, nil
+}
+
+// LooksLikeJWT tells JWTs apart from API keys, which contain no dots.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrUnauthenticated)
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrUnauthenticated)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrUnauthenticated)
+	}
+	if err := v.verifySignature(ctx, header, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrUnauthenticated)
+	}
+	if err := v.validateClaims(claims, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	return v.principal(claims)
+}
+
+func decodeSegment(seg string, dst interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+func (v *JWTVerifier) verifySignature(ctx context.Context, header jwtHeader, signed, sig []byte) error {
+	hash, err := jwtHash(header.Alg)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(header.Alg, "HS") {
+		// Only a configured shared secret may be used for HMAC, never a
+		// public key, or anyone could forge tokens with it.
+		if v.hmacKey == nil {
+			return fmt.Errorf("algorithm %s is not accepted", header.Alg)
+		}
+		mac := hmac.New(hash.New, v.hmacKey)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+
+	key := v.static
+	if v.jwks != nil {
+		if key, err = v.jwks.key(ctx, header.Kid); err != nil {
+			return err
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("algorithm %s is not accepted", header.Alg)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch header.Alg[:2] {
+		case "RS":
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		case "PS":
+			err = rsa.VerifyPSS(pub, hash, digest, sig, nil)
+		default:
+			err = fmt.Errorf("algorithm %s does not match an RSA key", header.Alg)
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if header.Alg[:2] != "ES" || len(sig) != 2*size {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			err = errors.New("invalid signature")
+		}
+	default:
+		err = fmt.Errorf("unsupported key type %T", key)
+	}
+	return err
+}
+
+func jwtHash(alg string) (crypto.Hash, error) {
+	if len(alg) != 5 {
+		return 0, fmt.Errorf("algorithm %q is not accepted", alg)
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("algorithm %q is not accepted", alg)
+}
+
+func (v *JWTVerifier) validateClaims(claims map[string]interface{}, now time.Time) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token not valid yet")
+	}
+	if v.cfg.Issuer != "" && claims["iss"] != v.cfg.Issuer {
+		return errors.New("unexpected issuer")
+	}
+	if v.cfg.Audience != "" && !claimContains(claims["aud"], v.cfg.Audience) {
+		return errors.New("unexpected audience")
+	}
+	return nil
+}
+
+// principal maps the tenant, module and scope claims; tokens without a
+// tenant claim belong to the default tenant. Scope claims may be a space
+// separated string, as in OAuth, or a list; ScopePrefix is stripped and
+// scopes we do not know are ignored. Only admins may omit the module.
+func (v *JWTVerifier) principal(claims map[string]interface{}) (*Principal, error) {
+	var scopes []string
+	for _, s := range claimStrings(claims[v.cfg.ScopesClaim]) {
+		s = strings.TrimPrefix(s, v.cfg.ScopePrefix)
+		if models.StringList(models.AllScopes).Contains(s) {
+			scopes = append(scopes, s)
+		}
+	}
+	module, _ := claims[v.cfg.ModuleClaim].(string)
+	sub, _ := claims["sub"].(string)
+	tenantID, _ := claims[v.cfg.TenantClaim].(string)
+	if tenantID == "" {
+		tenantID = tenant.Default
+	}
+
+	p := &Principal{KeyID: "jwt:" + sub, Name: sub, TenantID: tenantID, Module: module, Scopes: scopes}
+	if module == "" && !p.HasScope(models.ScopeAdmin) {
+		return nil, fmt.Errorf("%w: token has no %s claim", ErrUnauthenticated, v.cfg.ModuleClaim)
+	}
+	return p, nil
+}
+
+func claimStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		var out []string
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func claimContains(v interface{}, want string) bool {
+	for _, s := range claimStrings(v) {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
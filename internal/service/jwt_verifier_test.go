@@ -0,0 +1,168 @@
+package service_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func signJWT(t *testing.T, alg, kid string, claims map[string]interface{}, sign func([]byte) []byte) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+	return signed + "." + b64(sign([]byte(signed)))
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return sig
+	}
+}
+
+// jwksServer serves the public halves of keys, which tests may swap to
+// simulate rotation at the identity provider.
+type jwksServer struct {
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+	hits int
+	*httptest.Server
+}
+
+func newJWKSServer(t *testing.T, keys map[string]*rsa.PrivateKey) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.hits++
+		var set []map[string]string
+		for kid, k := range s.keys {
+			set = append(set, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+				"n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": set})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) fetches() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+func (s *jwksServer) rotate(keys map[string]*rsa.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func TestJWTVerifier_JWKSClaimsAndRotation(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := newJWKSServer(t, map[string]*rsa.PrivateKey{"k1": key1})
+
+	v, err := service.NewJWTVerifier(config.JWTConfig{
+		JWKSURL:     idp.URL,
+		Issuer:      "https://idp.example",
+		Audience:    "ai-service",
+		ScopePrefix: "ai:",
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	claims := func(extra map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "svc-support", "iss": "https://idp.example", "aud": []string{"ai-service"},
+			"exp": time.Now().Add(time.Hour).Unix(), "module": "support", "scope": "ai:send ai:prompts:read openid",
+		}
+		for k, val := range extra {
+			c[k] = val
+		}
+		return c
+	}
+
+	p, err := v.Verify(ctx, signJWT(t, "RS256", "k1", claims(nil), rs256(t, key1)))
+	require.NoError(t, err)
+	assert.Equal(t, "support", p.Module)
+	assert.Equal(t, []string{models.ScopeSend, models.ScopePromptsRead}, p.Scopes)
+	assert.Equal(t, "svc-support", p.Name)
+
+	for name, token := range map[string]string{
+		"expired":        signJWT(t, "RS256", "k1", claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}), rs256(t, key1)),
+		"wrong audience": signJWT(t, "RS256", "k1", claims(map[string]interface{}{"aud": "other"}), rs256(t, key1)),
+		"wrong issuer":   signJWT(t, "RS256", "k1", claims(map[string]interface{}{"iss": "https://evil"}), rs256(t, key1)),
+		"no module":      signJWT(t, "RS256", "k1", claims(map[string]interface{}{"module": nil}), rs256(t, key1)),
+		"bad signature":  signJWT(t, "RS256", "k1", claims(nil), rs256(t, key2)),
+		"hmac downgrade": signJWT(t, "HS256", "k1", claims(nil), func(b []byte) []byte {
+			mac := hmac.New(sha256.New, []byte("guess"))
+			mac.Write(b)
+			return mac.Sum(nil)
+		}),
+	} {
+		_, err := v.Verify(ctx, token)
+		assert.ErrorIs(t, err, service.ErrUnauthenticated, name)
+	}
+
+	// The provider rotates to a new key: the unknown kid triggers a refetch.
+	idp.rotate(map[string]*rsa.PrivateKey{"k2": key2})
+	hits := idp.fetches()
+	_, err = v.Verify(ctx, signJWT(t, "RS256", "k2", claims(nil), rs256(t, key2)))
+	require.NoError(t, err)
+	assert.Equal(t, hits+1, idp.fetches())
+
+	// Unknown kids do not refetch again right away.
+	_, err = v.Verify(ctx, signJWT(t, "RS256", "k3", claims(nil), rs256(t, key2)))
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+	assert.Equal(t, hits+1, idp.fetches())
+}
+
+func TestJWTVerifier_StaticHMACKey(t *testing.T) {
+	secret := []byte("shared-secret-for-tests")
+	v, err := service.NewJWTVerifier(config.JWTConfig{StaticKey: string(secret), ScopesClaim: "scp"})
+	require.NoError(t, err)
+
+	hs256 := func(b []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(b)
+		return mac.Sum(nil)
+	}
+	token := signJWT(t, "HS256", "", map[string]interface{}{
+		"sub": "ops", "exp": time.Now().Add(time.Minute).Unix(), "scp": []string{"admin"},
+	}, hs256)
+
+	p, err := v.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.Empty(t, p.Module, "admins may act on every module")
+	assert.True(t, p.HasScope(models.ScopePromptsWrite))
+	assert.True(t, service.LooksLikeJWT(token))
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"gorm.io/gorm"
+)
+
+// The canned prompts of model checks need no JSON escaping, so templates
+// that insert the prompts as they are still render valid JSON.
+const (
+	checkSystemPrompt = "You are a connectivity check."
+	checkUserPrompt   = "Reply with the single word OK."
+)
+
+// checkModel renders the request template of m with the canned prompts
+// and checks that the result is JSON and that the response paths are well
+// formed. It returns the rendered body, nil if the template is broken,
+// and the problems found.
+func checkModel(m *config.ModelConfig) ([]byte, []string) {
+	var problems []string
+	body, err := renderRequest(m, checkSystemPrompt, checkUserPrompt)
+	if err != nil {
+		problems = append(problems, err.Error())
+	} else if !json.Valid(body) {
+		problems = append(problems, "request template does not render valid JSON")
+	}
+	if m.ResponsePath == "" {
+		problems = append(problems, "response_path is required")
+	}
+	for _, p := range []struct{ name, path string }{
+		{"response_path", m.ResponsePath},
+		{"prompt_tokens_path", m.PromptTokensPath},
+		{"completion_tokens_path", m.CompletionTokensPath},
+	} {
+		if p.path != "" && !validPath(p.path) {
+			problems = append(problems, fmt.Sprintf("%s %q has an empty segment", p.name, p.path))
+		}
+	}
+	return body, problems
+}
+
+func validPath(path string) bool {
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckModels checks the request template and response paths of every
+// model, configured or registered, and reports all problems at once.
+func (s *ProviderService) CheckModels(ctx context.Context) error {
+	current, err := s.Current(ctx)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, p := range current.Providers {
+		for i := range p.Models {
+			_, found := checkModel(&p.Models[i])
+			for _, problem := range found {
+				problems = append(problems, fmt.Sprintf("%s/%s: %s", p.Name, p.Models[i].Name, problem))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ModelTestResult reports a canned request sent to a model.
+type ModelTestResult struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	OK       bool   `json:"ok"`
+	// Request is the rendered request body and Response the raw body the
+	// provider answered with.
+	Request          string   `json:"request"`
+	Response         string   `json:"response"`
+	Text             string   `json:"text"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	LatencyMs        int64    `json:"latency_ms"`
+	Errors           []string `json:"errors"`
+}
+
+// TestModel sends a canned prompt to a model with the API key the
+// caller's tenant would use and reports each step: the rendered request,
+// the raw response, the extracted text and token counts, and what went
+// wrong. The request is not logged as usage.
+func (s *SystemPromptService) TestModel(ctx context.Context, providerName, modelName string) (*ModelTestResult, error) {
+	current, err := s.providers.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider := findProvider(current, providerName)
+	if provider == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	model := findModel(provider, modelName)
+	if model == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if provider, err = s.credentials.Apply(ctx, "", provider); err != nil {
+		return nil, err
+	}
+
+	res := &ModelTestResult{Provider: provider.Name, Model: model.Name, Errors: []string{}}
+	body, problems := checkModel(model)
+	res.Request = string(body)
+	res.Errors = append(res.Errors, problems...)
+	if body == nil {
+		return res, nil
+	}
+
+	start := time.Now()
+	raw, err := postToProvider(ctx, provider, completionURL(provider, model), bytes.NewReader(body))
+	res.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Errors = append(res.Errors, hideKey(err.Error(), provider.APIKey))
+		return res, nil
+	}
+	res.Response = string(raw)
+
+	var result interface{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("invalid JSON response: %v", err))
+		return res, nil
+	}
+	if res.Text, err = s.extractResponse(result, model.ResponsePath); err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("response_path %q: %v", model.ResponsePath, err))
+	}
+	for _, p := range []struct {
+		name, path string
+		count      *int
+	}{
+		{"prompt_tokens_path", model.PromptTokensPath, &res.PromptTokens},
+		{"completion_tokens_path", model.CompletionTokensPath, &res.CompletionTokens},
+	} {
+		if p.path == "" {
+			continue
+		}
+		n, err := lookupPath(result, p.path)
+		if err == nil {
+			if f, ok := n.(float64); ok {
+				*p.count = int(f)
+				continue
+			}
+			err = errors.New("not a number")
+		}
+		res.Errors = append(res.Errors, fmt.Sprintf("%s %q: %v", p.name, p.path, err))
+	}
+	res.OK = len(res.Errors) == 0
+	return res, nil
+}
+
+// hideKey keeps the API key of query_param providers, which is part of
+// the request URL, out of error messages.
+func hideKey(msg, apiKey string) string {
+	if apiKey == "" {
+		return msg
+	}
+	return strings.ReplaceAll(msg, apiKey, "***")
+}
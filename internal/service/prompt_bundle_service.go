@@ -0,0 +1,467 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// BundleVersion is the version of the bundle format written by
+// ExportBundle and accepted by ImportBundle.
+const BundleVersion = 1
+
+const (
+	BundleFormatYAML = "yaml"
+	BundleFormatJSON = "json"
+
+	// ImportUpsert creates and updates what the bundle contains and leaves
+	// everything else alone. ImportReplace also deletes the prompts and
+	// rate limits the bundle does not contain; deleted prompts go to the
+	// trash. Unnamed prompts cannot be in a bundle and are never deleted.
+	ImportUpsert  = "upsert"
+	ImportReplace = "replace"
+)
+
+const (
+	bundleKindPrompt    = "prompt"
+	bundleKindLabel     = "label"
+	bundleKindRateLimit = "rate_limit"
+
+	importNote = "imported from bundle"
+)
+
+// ErrInvalidBundle is returned for bundles that cannot be parsed or
+// applied as a whole.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// errDryRun rolls back the transaction of a dry-run import.
+var errDryRun = errors.New("dry run")
+
+// Bundle is a portable copy of a tenant's prompts and rate limits, meant to
+// be kept in git. Prompts are matched by module and name, so prompts
+// without a name are not exported.
+type Bundle struct {
+	Version    int               `json:"version" yaml:"version"`
+	Prompts    []BundlePrompt    `json:"prompts" yaml:"prompts"`
+	RateLimits []BundleRateLimit `json:"rate_limits,omitempty" yaml:"rate_limits,omitempty"`
+}
+
+// BundlePrompt is the latest version of a prompt and the model it is bound
+// to. Labels lists the labels pointing at that version; labels on older
+// versions are not exported, and an import never removes labels.
+type BundlePrompt struct {
+	Module       string                 `json:"module" yaml:"module"`
+	Name         string                 `json:"name" yaml:"name"`
+	Provider     string                 `json:"provider" yaml:"provider"`
+	Model        string                 `json:"model" yaml:"model"`
+	SystemPrompt string                 `json:"system_prompt" yaml:"system_prompt"`
+	Variables    models.PromptVariables `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Labels       []string               `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+type BundleRateLimit struct {
+	Module      string `json:"module" yaml:"module"`
+	Provider    string `json:"provider" yaml:"provider"`
+	MaxRequests int    `json:"max_requests" yaml:"max_requests"`
+	PerSeconds  int    `json:"per_seconds" yaml:"per_seconds"`
+}
+
+func (p BundlePrompt) key() string    { return p.Module + "/" + p.Name }
+func (l BundleRateLimit) key() string { return l.Module + "/" + l.Provider }
+
+// ParseBundle decodes a JSON or YAML bundle. Unknown fields are rejected so
+// that typos in hand-edited files do not go unnoticed.
+func ParseBundle(raw []byte) (*Bundle, error) {
+	var b Bundle
+	var err error
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&b)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(raw))
+		dec.KnownFields(true)
+		err = dec.Decode(&b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	return &b, nil
+}
+
+// Encode renders the bundle in the given format, YAML by default.
+func (b *Bundle) Encode(format string) ([]byte, error) {
+	switch format {
+	case BundleFormatYAML, "":
+		return yaml.Marshal(b)
+	case BundleFormatJSON:
+		return json.MarshalIndent(b, "", "  ")
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidBundle, format)
+}
+
+// Merge appends the prompts and rate limits of other, so a bundle can be
+// split over several files.
+func (b *Bundle) Merge(other *Bundle) {
+	if b.Version == 0 {
+		b.Version = other.Version
+	}
+	b.Prompts = append(b.Prompts, other.Prompts...)
+	b.RateLimits = append(b.RateLimits, other.RateLimits...)
+}
+
+// validate checks what can be checked without the database. Names,
+// labels and variables are validated when they are applied.
+func (b *Bundle) validate(module string) error {
+	if b.Version != BundleVersion {
+		return fmt.Errorf("%w: version %d is not supported, want %d", ErrInvalidBundle, b.Version, BundleVersion)
+	}
+	seen := make(map[string]bool)
+	for _, p := range b.Prompts {
+		switch {
+		case p.Module == "" || p.Name == "":
+			return fmt.Errorf("%w: every prompt needs a module and a name", ErrInvalidBundle)
+		case module != "" && p.Module != module:
+			return fmt.Errorf("%w: prompt %s is outside module %s", ErrInvalidBundle, p.key(), module)
+		case p.Provider == "" || p.Model == "" || p.SystemPrompt == "":
+			return fmt.Errorf("%w: prompt %s needs a provider, model and system_prompt", ErrInvalidBundle, p.key())
+		case seen[p.key()]:
+			return fmt.Errorf("%w: prompt %s appears more than once", ErrInvalidBundle, p.key())
+		}
+		seen[p.key()] = true
+	}
+
+	seen = make(map[string]bool)
+	for _, l := range b.RateLimits {
+		switch {
+		case l.Module == "" || l.Provider == "":
+			return fmt.Errorf("%w: every rate limit needs a module and a provider", ErrInvalidBundle)
+		case module != "" && l.Module != module:
+			return fmt.Errorf("%w: rate limit %s is outside module %s", ErrInvalidBundle, l.key(), module)
+		case l.MaxRequests <= 0 || l.PerSeconds <= 0:
+			return fmt.Errorf("%w: rate limit %s needs positive max_requests and per_seconds", ErrInvalidBundle, l.key())
+		case seen[l.key()]:
+			return fmt.Errorf("%w: rate limit %s appears more than once", ErrInvalidBundle, l.key())
+		}
+		seen[l.key()] = true
+	}
+	return nil
+}
+
+// ExportBundle returns the named prompts and the rate limits of the
+// tenant, or of one module when module is set.
+func (s *SystemPromptService) ExportBundle(ctx context.Context, module string) (*Bundle, error) {
+	prompts, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{Version: BundleVersion, Prompts: []BundlePrompt{}}
+	for _, sp := range prompts {
+		if sp.Name == "" || (module != "" && sp.ModuleName != module) {
+			continue
+		}
+		labels, err := s.repo.ListLabels(ctx, sp.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		p := BundlePrompt{
+			Module:       sp.ModuleName,
+			Name:         sp.Name,
+			Provider:     sp.Provider,
+			Model:        sp.ModelName,
+			SystemPrompt: sp.SystemPrompt,
+			Variables:    sp.Variables,
+		}
+		for _, l := range labels {
+			if l.Label != models.LabelDraft && l.Version == sp.Version {
+				p.Labels = append(p.Labels, l.Label)
+			}
+		}
+		b.Prompts = append(b.Prompts, p)
+	}
+	sort.Slice(b.Prompts, func(i, j int) bool { return b.Prompts[i].key() < b.Prompts[j].key() })
+
+	limits, err := s.rateLimits.List(ctx, module)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range limits {
+		b.RateLimits = append(b.RateLimits, BundleRateLimit{
+			Module:      l.ModuleName,
+			Provider:    l.Provider,
+			MaxRequests: l.MaxRequests,
+			PerSeconds:  l.PerSeconds,
+		})
+	}
+	return b, nil
+}
+
+// ImportOptions control how ImportBundle applies a bundle.
+type ImportOptions struct {
+	// Module limits the import to one module: the bundle may not contain
+	// anything else, and replace only deletes within the module.
+	Module string
+	// Mode is ImportUpsert (the default) or ImportReplace.
+	Mode   string
+	DryRun bool
+	// Author is recorded on the prompt versions the import creates.
+	Author string
+
+	// configManaged is set by the prompt file sync, whose imports own the
+	// config-managed prompts: replace deletes only those, and rate limits
+	// are left alone. Other imports cannot change them, and replace
+	// leaves them in place.
+	configManaged bool
+}
+
+// BundleChange is one change an import made or, in a dry run, would make.
+type BundleChange struct {
+	Action string     `json:"action"` // "create", "update" or "delete"
+	Kind   string     `json:"kind"`   // "prompt", "label" or "rate_limit"
+	Key    string     `json:"key"`
+	Fields []string   `json:"fields,omitempty"`
+	Diff   []DiffLine `json:"diff,omitempty"`
+}
+
+type ImportResult struct {
+	DryRun    bool           `json:"dry_run"`
+	Mode      string         `json:"mode"`
+	Changes   []BundleChange `json:"changes"`
+	Unchanged int            `json:"unchanged"`
+}
+
+// ImportBundle applies a bundle in one transaction, so either all of it
+// takes effect or none of it does. A dry run applies the bundle the same
+// way, reports the changes and rolls them back.
+func (s *SystemPromptService) ImportBundle(ctx context.Context, b *Bundle, opts ImportOptions) (*ImportResult, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportUpsert
+	}
+	if opts.Mode != ImportUpsert && opts.Mode != ImportReplace {
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidBundle, opts.Mode)
+	}
+	if err := b.validate(opts.Module); err != nil {
+		return nil, err
+	}
+
+	if opts.configManaged {
+		ctx = context.WithValue(ctx, promptFilesKey{}, true)
+	}
+	res := &ImportResult{DryRun: opts.DryRun, Mode: opts.Mode, Changes: []BundleChange{}}
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.importPrompts(txCtx, b.Prompts, opts, res); err != nil {
+			return err
+		}
+		if !opts.configManaged {
+			if err := s.importRateLimits(txCtx, b.RateLimits, opts, res); err != nil {
+				return err
+			}
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *SystemPromptService) importPrompts(ctx context.Context, prompts []BundlePrompt, opts ImportOptions, res *ImportResult) error {
+	existing, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]*models.SystemPrompt)
+	for i := range existing {
+		if sp := &existing[i]; sp.Name != "" {
+			current[sp.ModuleName+"/"+sp.Name] = sp
+		}
+	}
+
+	wanted := make(map[string]bool)
+	for _, p := range prompts {
+		wanted[p.key()] = true
+		fields := PromptFields{
+			Name:         p.Name,
+			Provider:     p.Provider,
+			ModelName:    p.Model,
+			SystemPrompt: p.SystemPrompt,
+			// An empty list, unlike nil, clears the variables on update.
+			Variables: append(models.PromptVariables{}, p.Variables...),
+		}
+
+		sp, ok := current[p.key()]
+		if !ok {
+			if sp, err = s.Create(ctx, p.Module, fields, opts.Author, importNote); err != nil {
+				return fmt.Errorf("prompt %s: %w", p.key(), err)
+			}
+			res.Changes = append(res.Changes, BundleChange{
+				Action: models.AuditActionCreate, Kind: bundleKindPrompt, Key: p.key(),
+			})
+		} else if changed := promptChanges(sp, p, opts.configManaged); len(changed) > 0 {
+			change := BundleChange{
+				Action: models.AuditActionUpdate, Kind: bundleKindPrompt, Key: p.key(), Fields: changed,
+			}
+			if sp.SystemPrompt != p.SystemPrompt {
+				change.Diff = diffLines(sp.SystemPrompt, p.SystemPrompt)
+			}
+			if sp, err = s.Update(ctx, sp.ID.String(), fields, nil, opts.Author, importNote); err != nil {
+				return fmt.Errorf("prompt %s: %w", p.key(), err)
+			}
+			res.Changes = append(res.Changes, change)
+		} else {
+			res.Unchanged++
+		}
+
+		if err := s.importLabels(ctx, sp, p, opts, res); err != nil {
+			return err
+		}
+	}
+
+	if opts.Mode != ImportReplace {
+		return nil
+	}
+	for _, sp := range existing {
+		if (opts.Module != "" && sp.ModuleName != opts.Module) || sp.ConfigManaged != opts.configManaged {
+			continue
+		}
+		key := sp.ModuleName + "/" + sp.Name
+		if sp.Name == "" || wanted[key] {
+			continue
+		}
+		if err := s.Delete(ctx, sp.ID.String()); err != nil {
+			return fmt.Errorf("prompt %s: %w", key, err)
+		}
+		res.Changes = append(res.Changes, BundleChange{
+			Action: models.AuditActionDelete, Kind: bundleKindPrompt, Key: key,
+		})
+	}
+	return nil
+}
+
+// promptChanges lists the bundle fields in which p differs from sp, and
+// "config_managed" when a file takes over a prompt created through the API.
+func promptChanges(sp *models.SystemPrompt, p BundlePrompt, configManaged bool) []string {
+	var changed []string
+	if configManaged && !sp.ConfigManaged {
+		changed = append(changed, "config_managed")
+	}
+	if sp.Provider != p.Provider {
+		changed = append(changed, "provider")
+	}
+	if sp.ModelName != p.Model {
+		changed = append(changed, "model")
+	}
+	if sp.SystemPrompt != p.SystemPrompt {
+		changed = append(changed, "system_prompt")
+	}
+	if !sameVariables(sp.Variables, p.Variables) {
+		changed = append(changed, "variables")
+	}
+	return changed
+}
+
+// sameVariables compares declarations by their JSON form, so a default of
+// 3 read from YAML equals the 3.0 read back from the database.
+func sameVariables(a, b models.PromptVariables) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// importLabels points the bundle's labels at the prompt's latest version.
+func (s *SystemPromptService) importLabels(ctx context.Context, sp *models.SystemPrompt, p BundlePrompt, opts ImportOptions, res *ImportResult) error {
+	for _, label := range p.Labels {
+		key := p.key() + "@" + label
+		action := models.AuditActionUpdate
+		l, err := s.repo.GetLabel(ctx, sp.ID.String(), label)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			action = models.AuditActionCreate
+		case err != nil:
+			return err
+		case l.Version == sp.Version:
+			res.Unchanged++
+			continue
+		}
+		if _, err := s.SetLabel(ctx, sp.ID.String(), label, sp.Version, opts.Author); err != nil {
+			return fmt.Errorf("label %s: %w", key, err)
+		}
+		res.Changes = append(res.Changes, BundleChange{Action: action, Kind: bundleKindLabel, Key: key})
+	}
+	return nil
+}
+
+func (s *SystemPromptService) importRateLimits(ctx context.Context, limits []BundleRateLimit, opts ImportOptions, res *ImportResult) error {
+	existing, err := s.rateLimits.List(ctx, opts.Module)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]*models.RateLimit)
+	for i := range existing {
+		current[existing[i].ModuleName+"/"+existing[i].Provider] = &existing[i]
+	}
+
+	wanted := make(map[string]bool)
+	for _, want := range limits {
+		wanted[want.key()] = true
+		change := BundleChange{Action: models.AuditActionUpdate, Kind: bundleKindRateLimit, Key: want.key()}
+		var before interface{}
+		l, ok := current[want.key()]
+		if !ok {
+			change.Action = models.AuditActionCreate
+			l = &models.RateLimit{ModuleName: want.Module, Provider: want.Provider}
+		} else {
+			if l.MaxRequests != want.MaxRequests {
+				change.Fields = append(change.Fields, "max_requests")
+			}
+			if l.PerSeconds != want.PerSeconds {
+				change.Fields = append(change.Fields, "per_seconds")
+			}
+			if len(change.Fields) == 0 {
+				res.Unchanged++
+				continue
+			}
+			before = snapshot(l)
+		}
+
+		l.MaxRequests, l.PerSeconds = want.MaxRequests, want.PerSeconds
+		if err := s.rateLimits.Save(ctx, l); err != nil {
+			return fmt.Errorf("rate limit %s: %w", want.key(), err)
+		}
+		if err := s.audit.Record(ctx, change.Action, models.AuditResourceRateLimit, l.ID.String(), before, l); err != nil {
+			return err
+		}
+		res.Changes = append(res.Changes, change)
+	}
+
+	if opts.Mode != ImportReplace {
+		return nil
+	}
+	for _, l := range existing {
+		key := l.ModuleName + "/" + l.Provider
+		if wanted[key] {
+			continue
+		}
+		if err := s.rateLimits.Delete(ctx, l.ID.String()); err != nil {
+			return fmt.Errorf("rate limit %s: %w", key, err)
+		}
+		if err := s.audit.Record(ctx, models.AuditActionDelete, models.AuditResourceRateLimit, l.ID.String(), l, nil); err != nil {
+			return err
+		}
+		res.Changes = append(res.Changes, BundleChange{Action: models.AuditActionDelete, Kind: bundleKindRateLimit, Key: key})
+	}
+	return nil
+}
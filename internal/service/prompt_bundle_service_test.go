@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBundle = `
+version: 1
+prompts:
+  - module: support
+    name: greeting
+    provider: openai
+    model: fake-model
+    system_prompt: |-
+      Greet {{.name}}.
+      Be brief.
+    variables:
+      - name: name
+        type: string
+        default: friend
+    labels: [production]
+  - module: support
+    name: farewell
+    provider: openai
+    model: fake-model
+    system_prompt: Say goodbye.
+rate_limits:
+  - module: support
+    provider: openai
+    max_requests: 10
+    per_seconds: 60
+`
+
+func TestSystemPromptService_ImportAndExportBundles(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+
+	unnamed, err := svc.Create(ctx, "support", service.PromptFields{
+		Provider: "openai", ModelName: "fake-model", SystemPrompt: "No name.",
+	}, "", "")
+	require.NoError(t, err)
+
+	bundle, err := service.ParseBundle([]byte(testBundle))
+	require.NoError(t, err)
+
+	dry, err := svc.ImportBundle(ctx, bundle, service.ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, dry.Changes, 4, "two prompts, a label and a rate limit")
+	_, err = svc.GetByName(ctx, "support", "greeting")
+	assert.Error(t, err, "a dry run applies nothing")
+
+	applied, err := svc.ImportBundle(ctx, bundle, service.ImportOptions{Author: "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, dry.Changes, applied.Changes)
+	greeting, err := svc.GetByName(ctx, "support", "greeting")
+	require.NoError(t, err)
+	label, err := repository.NewSystemPromptRepo(db).GetLabel(ctx, greeting.ID.String(), models.LabelProduction)
+	require.NoError(t, err)
+	assert.Equal(t, greeting.Version, label.Version)
+
+	again, err := svc.ImportBundle(ctx, bundle, service.ImportOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, again.Changes, "importing the same bundle twice is a no-op")
+	assert.Equal(t, 4, again.Unchanged)
+
+	// The export reads back as the bundle that was imported.
+	exported, err := svc.ExportBundle(ctx, "support")
+	require.NoError(t, err)
+	for _, format := range []string{service.BundleFormatYAML, service.BundleFormatJSON} {
+		raw, err := exported.Encode(format)
+		require.NoError(t, err)
+		reparsed, err := service.ParseBundle(raw)
+		require.NoError(t, err)
+		result, err := svc.ImportBundle(ctx, reparsed, service.ImportOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes, format)
+	}
+
+	// Replace deletes what the bundle lacks and reports text changes as a diff.
+	bundle.Prompts = bundle.Prompts[:1]
+	bundle.Prompts[0].SystemPrompt = "Greet {{.name}}.\nBe kind."
+	bundle.RateLimits = nil
+	replaced, err := svc.ImportBundle(ctx, bundle, service.ImportOptions{Mode: service.ImportReplace})
+	require.NoError(t, err)
+	byKey := map[string]service.BundleChange{}
+	for _, c := range replaced.Changes {
+		byKey[c.Kind+" "+c.Key] = c
+	}
+	require.Len(t, byKey, 4)
+	update := byKey["prompt support/greeting"]
+	assert.Equal(t, models.AuditActionUpdate, update.Action)
+	assert.Equal(t, []string{"system_prompt"}, update.Fields)
+	assert.Contains(t, update.Diff, service.DiffLine{Op: "+", Text: "Be kind."})
+	assert.Equal(t, models.AuditActionUpdate, byKey["label support/greeting@production"].Action)
+	assert.Equal(t, models.AuditActionDelete, byKey["prompt support/farewell"].Action)
+	assert.Equal(t, models.AuditActionDelete, byKey["rate_limit support/openai"].Action)
+	_, err = svc.GetByName(ctx, "support", "farewell")
+	assert.Error(t, err)
+	_, err = svc.GetByID(ctx, unnamed.ID.String())
+	assert.NoError(t, err, "unnamed prompts are not in bundles and are kept")
+
+	// Replacing with an export of the module changes nothing.
+	exported, err = svc.ExportBundle(ctx, "support")
+	require.NoError(t, err)
+	roundTrip, err := svc.ImportBundle(ctx, exported, service.ImportOptions{Mode: service.ImportReplace, Module: "support"})
+	require.NoError(t, err)
+	assert.Empty(t, roundTrip.Changes)
+	_, err = svc.GetByID(ctx, unnamed.ID.String())
+	assert.NoError(t, err)
+
+	// A failing prompt rolls back the whole import.
+	bundle.Prompts = append(bundle.Prompts, service.BundlePrompt{
+		Module: "support", Name: "broken", Provider: "openai", Model: "fake-model", SystemPrompt: "Hi {{.name",
+	})
+	bundle.Prompts[0].SystemPrompt = "Changed again."
+	_, err = svc.ImportBundle(ctx, bundle, service.ImportOptions{})
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt)
+	greeting, err = svc.GetByName(ctx, "support", "greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "Greet {{.name}}.\nBe kind.", greeting.SystemPrompt)
+
+	_, err = svc.ImportBundle(ctx, bundle, service.ImportOptions{Module: "billing"})
+	assert.ErrorIs(t, err, service.ErrInvalidBundle)
+	_, err = service.ParseBundle([]byte("version: 1\nprompt: []\n"))
+	assert.ErrorIs(t, err, service.ErrInvalidBundle, "unknown fields are rejected")
+}
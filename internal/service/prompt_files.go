@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// promptFilesSettle is how long the watcher waits for a burst of file
+// events to end before syncing; editors often save in several steps.
+const promptFilesSettle = 500 * time.Millisecond
+
+// ErrPromptManaged is returned for API changes to a prompt defined by a
+// file in the config prompts directory.
+var ErrPromptManaged = errors.New("prompt is managed by a config file and can only be changed there")
+
+// promptFilesKey marks the context of the prompt file sync, the only
+// writer allowed to change config-managed prompts.
+type promptFilesKey struct{}
+
+func fromPromptFiles(ctx context.Context) bool {
+	managed, _ := ctx.Value(promptFilesKey{}).(bool)
+	return managed
+}
+
+// checkEditable fails with ErrPromptManaged when sp belongs to a prompt
+// file and the change does not come from the file sync.
+func checkEditable(ctx context.Context, sp *models.SystemPrompt) error {
+	if sp.ConfigManaged && !fromPromptFiles(ctx) {
+		return fmt.Errorf("%w: %s/%s", ErrPromptManaged, sp.ModuleName, sp.Name)
+	}
+	return nil
+}
+
+// LoadPromptFiles reads the .yml and .yaml files of dir, each holding one
+// prompt in the form of a bundle entry:
+//
+//	module: support
+//	name: order-status
+//	provider: openai
+//	model: gpt-4o
+//	variables:
+//	  - name: order_id
+//	    type: string
+//	    required: true
+//	system_prompt: |
+//	  Look up order {{.order_id}} ...
+//
+// A missing directory holds no prompts.
+func LoadPromptFiles(dir string) ([]BundlePrompt, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var prompts []BundlePrompt
+	for _, e := range entries {
+		if e.IsDir() || !isPromptFile(e.Name()) {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var p BundlePrompt
+		dec := yaml.NewDecoder(bytes.NewReader(raw))
+		dec.KnownFields(true)
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, e.Name(), err)
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, nil
+}
+
+func isPromptFile(name string) bool {
+	ext := filepath.Ext(name)
+	return !strings.HasPrefix(name, ".") && (ext == ".yml" || ext == ".yaml")
+}
+
+// SyncPromptFiles makes the config-managed prompts of the tenant of ctx
+// match the files in dir: prompts are created or updated from their file,
+// including ones created through the API, which the file then takes over,
+// and managed prompts whose file is gone are moved to the trash. Like any
+// import, a sync is applied completely or not at all.
+func (s *SystemPromptService) SyncPromptFiles(ctx context.Context, dir string) (*ImportResult, error) {
+	prompts, err := LoadPromptFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	ctx = audit.WithActor(ctx, audit.Actor{Name: "prompt files"})
+	return s.ImportBundle(ctx, &Bundle{Version: BundleVersion, Prompts: prompts}, ImportOptions{
+		Mode:          ImportReplace,
+		Author:        "config",
+		configManaged: true,
+	})
+}
+
+// WatchPromptFiles syncs dir whenever its files change, until ctx is done,
+// and passes the outcome of every sync to report. A missing directory is
+// not watched.
+func (s *SystemPromptService) WatchPromptFiles(ctx context.Context, dir string, report func(*ImportResult, error)) error {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := w.Add(dir); err != nil {
+		w.Close()
+		return err
+	}
+
+	go func() {
+		defer w.Close()
+		var settle <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				// Not filtered by name: mounted config maps swap whole
+				// directories through symlinks.
+				if ev.Op != fsnotify.Chmod {
+					settle = time.After(promptFilesSettle)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				report(nil, err)
+			case <-settle:
+				settle = nil
+				report(s.SyncPromptFiles(ctx, dir))
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePromptFile(t *testing.T, dir, name, text string) {
+	t.Helper()
+	content := "module: support\nname: " + name + "\nprovider: openai\nmodel: fake-model\nsystem_prompt: " + text + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".yml"), []byte(content), 0o644))
+}
+
+func TestSystemPromptService_SyncsPromptFiles(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	adopted, err := svc.Create(ctx, "support", service.PromptFields{
+		Name: "refunds", Provider: "openai", ModelName: "fake-model", SystemPrompt: "Old refund policy.",
+	}, "", "")
+	require.NoError(t, err)
+	apiOnly, err := svc.Create(ctx, "support", service.PromptFields{
+		Name: "api-only", Provider: "openai", ModelName: "fake-model", SystemPrompt: "Made by hand.",
+	}, "", "")
+	require.NoError(t, err)
+
+	writePromptFile(t, dir, "greeting", "Say hello.")
+	writePromptFile(t, dir, "refunds", "New refund policy.")
+	res, err := svc.SyncPromptFiles(ctx, dir)
+	require.NoError(t, err)
+	assert.Len(t, res.Changes, 2)
+
+	greeting, err := svc.GetByName(ctx, "support", "greeting")
+	require.NoError(t, err)
+	assert.True(t, greeting.ConfigManaged)
+	refunds, err := svc.GetByID(ctx, adopted.ID.String())
+	require.NoError(t, err)
+	assert.True(t, refunds.ConfigManaged, "the file takes over a prompt of the same name")
+	assert.Equal(t, "New refund policy.", refunds.SystemPrompt)
+
+	// Managed prompts are read-only through the API.
+	_, err = svc.Update(ctx, greeting.ID.String(), service.PromptFields{SystemPrompt: "Hi."}, nil, "", "")
+	assert.ErrorIs(t, err, service.ErrPromptManaged)
+	assert.ErrorIs(t, svc.Delete(ctx, greeting.ID.String()), service.ErrPromptManaged)
+	_, err = svc.Rollback(ctx, refunds.ID.String(), 1, "", "")
+	assert.ErrorIs(t, err, service.ErrPromptManaged)
+	keep := service.BundlePrompt{Module: "support", Name: "api-only", Provider: "openai", Model: "fake-model", SystemPrompt: "Made by hand."}
+	_, err = svc.ImportBundle(ctx, &service.Bundle{Version: service.BundleVersion, Prompts: []service.BundlePrompt{keep}},
+		service.ImportOptions{Mode: service.ImportReplace})
+	require.NoError(t, err)
+	_, err = svc.GetByID(ctx, greeting.ID.String())
+	assert.NoError(t, err, "a replacing import leaves managed prompts alone")
+
+	// A broken file fails the sync without changing anything.
+	writePromptFile(t, dir, "greeting", "Say hello, {{.name")
+	_, err = svc.SyncPromptFiles(ctx, dir)
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt)
+	greeting, err = svc.GetByID(ctx, greeting.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Say hello.", greeting.SystemPrompt)
+
+	// Removing a file moves its prompt to the trash; API prompts stay.
+	require.NoError(t, os.Remove(filepath.Join(dir, "greeting.yml")))
+	res, err = svc.SyncPromptFiles(ctx, dir)
+	require.NoError(t, err)
+	require.Len(t, res.Changes, 1)
+	assert.Equal(t, models.AuditActionDelete, res.Changes[0].Action)
+	_, err = svc.GetByID(ctx, greeting.ID.String())
+	assert.Error(t, err)
+	_, err = svc.GetByID(ctx, apiOnly.ID.String())
+	assert.NoError(t, err)
+}
+
+func TestSystemPromptService_WatchesPromptFiles(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+
+	synced := make(chan error, 10)
+	require.NoError(t, svc.WatchPromptFiles(ctx, dir, func(_ *service.ImportResult, err error) { synced <- err }))
+	writePromptFile(t, dir, "greeting", "Say hello.")
+
+	select {
+	case err := <-synced:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no sync after the file was written")
+	}
+	greeting, err := svc.GetByName(ctx, "support", "greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "Say hello.", greeting.SystemPrompt)
+}
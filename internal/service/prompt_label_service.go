@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var labelPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+func (s *SystemPromptService) ListLabels(ctx context.Context, id string) ([]models.PromptLabel, error) {
+	return s.repo.ListLabels(ctx, id)
+}
+
+// SetLabel points label at the given version of the prompt. The labels of
+// a deleted prompt cannot be moved until it is restored.
+func (s *SystemPromptService) SetLabel(ctx context.Context, id, label string, version int, actor string) (*models.PromptLabel, error) {
+	if !labelPattern.MatchString(label) {
+		return nil, fmt.Errorf("%w: invalid label %q", ErrInvalidPrompt, label)
+	}
+	if label == models.LabelDraft {
+		return nil, fmt.Errorf("%w: the %s label follows the latest version and cannot be set", ErrInvalidPrompt, label)
+	}
+
+	var l *models.PromptLabel
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		if _, err := s.repo.GetByID(txCtx, id); err != nil {
+			return err
+		}
+		v, err := s.repo.GetVersion(txCtx, id, version)
+		if err != nil {
+			return fmt.Errorf("version %d not found: %w", version, err)
+		}
+		l = labelFor(v, label, actor)
+		return s.upsertLabel(txCtx, l)
+	})
+	return l, err
+}
+
+// Promote moves the to label onto the version the from label points at,
+// e.g. staging -> production, in one transaction.
+func (s *SystemPromptService) Promote(ctx context.Context, id, from, to, actor string) (*models.PromptLabel, error) {
+	if !labelPattern.MatchString(to) || to == models.LabelDraft {
+		return nil, fmt.Errorf("%w: cannot promote to label %q", ErrInvalidPrompt, to)
+	}
+
+	var l *models.PromptLabel
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		src, err := s.repo.GetLabelForUpdate(txCtx, id, from)
+		if err != nil {
+			return fmt.Errorf("label %q not found: %w", from, err)
+		}
+		v, err := s.repo.GetVersionByID(txCtx, src.VersionID.String())
+		if err != nil {
+			return err
+		}
+		l = labelFor(v, to, actor)
+		return s.upsertLabel(txCtx, l)
+	})
+	return l, err
+}
+
+// upsertLabel moves or creates a label on behalf of an API caller and
+// audits the change. It must run inside the caller's transaction.
+func (s *SystemPromptService) upsertLabel(ctx context.Context, l *models.PromptLabel) error {
+	promptID := l.SystemPromptID.String()
+	action := models.AuditActionUpdate
+	var before interface{}
+	prev, err := s.repo.GetLabel(ctx, promptID, l.Label)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		action = models.AuditActionCreate
+	case err != nil:
+		return err
+	default:
+		before = snapshot(prev)
+	}
+	if err := s.repo.UpsertLabel(ctx, l); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, action, models.AuditResourcePromptLabel, promptID+"/"+l.Label, before, l)
+}
+
+func labelFor(v *models.SystemPromptVersion, label, actor string) *models.PromptLabel {
+	return &models.PromptLabel{
+		SystemPromptID: v.SystemPromptID,
+		ModuleName:     v.ModuleName,
+		Label:          label,
+		VersionID:      v.ID,
+		Version:        v.Version,
+		UpdatedBy:      actor,
+	}
+}
+
+// resolveLabel finds the version a label points at. Without a prompt ID the
+// label must be unique within the module.
+func (s *SystemPromptService) resolveLabel(ctx context.Context, module, promptID, label string) (*models.SystemPromptVersion, error) {
+	var l *models.PromptLabel
+	if promptID != "" {
+		found, err := s.repo.GetLabel(ctx, promptID, label)
+		if err != nil {
+			return nil, fmt.Errorf("label %q not found: %w", label, err)
+		}
+		l = found
+	} else {
+		labels, err := s.repo.FindLabelsInModule(ctx, module, label)
+		if err != nil {
+			return nil, err
+		}
+		switch len(labels) {
+		case 0:
+			return nil, fmt.Errorf("label %q not found in module %s", label, module)
+		case 1:
+			l = &labels[0]
+		default:
+			return nil, fmt.Errorf("label %q is used by %d prompts in module %s; pass prompt_id", label, len(labels), module)
+		}
+	}
+
+	if l.ModuleName != module {
+		return nil, fmt.Errorf("label %q does not belong to module %s", label, module)
+	}
+	return s.repo.GetVersionByID(ctx, l.VersionID.String())
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+)
+
+// ErrInvalidListQuery wraps bad sort columns and cursors.
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+const (
+	defaultPromptPageSize = 20
+	maxPromptPageSize     = 100
+)
+
+// PromptPage is one page of a prompt listing. NextCursor is empty on the
+// last page.
+type PromptPage struct {
+	Items      []models.SystemPrompt `json:"items"`
+	Total      int64                 `json:"total"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// List returns a page of prompts matching q. cursor is the NextCursor of
+// the previous page, or empty for the first one.
+func (s *SystemPromptService) List(ctx context.Context, q repository.PromptQuery, cursor string) (*PromptPage, error) {
+	if q.SortBy == "" {
+		q.SortBy = repository.PromptSortColumns[0]
+	}
+	if !models.StringList(repository.PromptSortColumns).Contains(q.SortBy) {
+		return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidListQuery, q.SortBy)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultPromptPageSize
+	}
+	if q.Limit > maxPromptPageSize {
+		q.Limit = maxPromptPageSize
+	}
+	if cursor != "" {
+		after, err := decodePromptCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.After = after
+	}
+
+	// One extra row tells whether there is a next page.
+	pageSize := q.Limit
+	q.Limit++
+	items, total, err := s.repo.ListPage(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	page := &PromptPage{Items: items, Total: total}
+	if len(items) > pageSize {
+		page.Items = items[:pageSize]
+		last := page.Items[pageSize-1]
+		at := last.CreatedAt
+		if q.SortBy == "updated_at" {
+			at = last.UpdatedAt
+		}
+		page.NextCursor = encodePromptCursor(repository.PromptCursor{At: at, ID: last.ID})
+	}
+	if page.Items == nil {
+		page.Items = []models.SystemPrompt{}
+	}
+	return page, nil
+}
+
+func encodePromptCursor(c repository.PromptCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePromptCursor(cursor string) (*repository.PromptCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
+	}
+	var c repository.PromptCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
+	}
+	return &c, nil
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemPromptService_ListPagesFiltersAndSearches(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+
+	var created []string
+	for i := 0; i < 5; i++ {
+		module := "support"
+		if i%2 == 1 {
+			module = "billing"
+		}
+		sp, err := svc.Create(ctx, module, service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: fmt.Sprintf("Prompt %d about refunds_%d.", i, i)}, "", "")
+		require.NoError(t, err)
+		created = append(created, sp.ID.String())
+	}
+	_, err := svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "other-model", SystemPrompt: "Talk about 100% of invoices."}, "", "")
+	require.NoError(t, err)
+
+	// Page through the prompts, oldest first.
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "paging must terminate")
+		page, err := svc.List(ctx, repository.PromptQuery{Model: "fake-model", Ascending: true, Limit: 2}, cursor)
+		require.NoError(t, err)
+		assert.EqualValues(t, 5, page.Total)
+		for _, sp := range page.Items {
+			seen = append(seen, sp.ID.String())
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Equal(t, created, seen)
+
+	newest, err := svc.List(ctx, repository.PromptQuery{Module: "billing", Limit: 1}, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, newest.Total)
+	require.Len(t, newest.Items, 1)
+	assert.Equal(t, created[3], newest.Items[0].ID.String())
+	assert.NotEmpty(t, newest.NextCursor)
+
+	found, err := svc.List(ctx, repository.PromptQuery{Search: "REFUNDS_2"}, "")
+	require.NoError(t, err)
+	require.Len(t, found.Items, 1)
+	assert.Equal(t, created[2], found.Items[0].ID.String())
+
+	found, err = svc.List(ctx, repository.PromptQuery{Search: "0%"}, "")
+	require.NoError(t, err)
+	require.Len(t, found.Items, 1, "LIKE wildcards in the search are matched literally")
+	assert.Empty(t, found.NextCursor)
+
+	_, err = svc.List(ctx, repository.PromptQuery{SortBy: "system_prompt"}, "")
+	assert.ErrorIs(t, err, service.ErrInvalidListQuery)
+	_, err = svc.List(ctx, repository.PromptQuery{}, "not a cursor!")
+	assert.ErrorIs(t, err, service.ErrInvalidListQuery)
+}
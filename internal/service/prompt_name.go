@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrPromptNameTaken is returned when another live prompt of the module
+// already has the name.
+var ErrPromptNameTaken = errors.New("prompt name is already in use")
+
+// Names are slugs such as "order-status" or "refund_policy.v2".
+var promptNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+func validatePromptName(name string) error {
+	if name != "" && !promptNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: name %q must be a lowercase slug of up to 100 letters, digits, '.', '_' or '-'",
+			ErrInvalidPrompt, name)
+	}
+	return nil
+}
+
+// checkNameFree fails with ErrPromptNameTaken when another live prompt of
+// sp's module has its name. The unique index catches races; this check
+// gives callers a clear error.
+func (s *SystemPromptService) checkNameFree(ctx context.Context, sp *models.SystemPrompt) error {
+	if sp.Name == "" {
+		return nil
+	}
+	other, err := s.repo.GetByName(ctx, sp.ModuleName, sp.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if other.ID != sp.ID {
+		return fmt.Errorf("%w: %s/%s", ErrPromptNameTaken, sp.ModuleName, sp.Name)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemPromptService_NamesAndConditionalUpdates(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+	fields := func(name, text string) service.PromptFields {
+		return service.PromptFields{Name: name, Provider: "openai", ModelName: "fake-model", SystemPrompt: text}
+	}
+
+	sp, err := svc.Create(ctx, "support", fields("order-status", "Report the order status."), "", "")
+	require.NoError(t, err)
+	found, err := svc.GetByName(ctx, "support", "order-status")
+	require.NoError(t, err)
+	assert.Equal(t, sp.ID, found.ID)
+
+	_, err = svc.Create(ctx, "support", fields("order-status", "Another."), "", "")
+	assert.ErrorIs(t, err, service.ErrPromptNameTaken)
+	_, err = svc.Create(ctx, "billing", fields("order-status", "Same name, other module."), "", "")
+	assert.NoError(t, err)
+	_, err = svc.Create(ctx, "support", fields("Order Status", "Not a slug."), "", "")
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt)
+
+	stale := sp.Version
+	updated, err := svc.Update(ctx, sp.ID.String(), service.PromptFields{ModelName: "other-model"}, &stale, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, stale+1, updated.Version)
+	assert.Equal(t, "other-model", updated.ModelName)
+	assert.Equal(t, "Report the order status.", updated.SystemPrompt, "omitted fields are kept")
+
+	_, err = svc.Update(ctx, sp.ID.String(), service.PromptFields{SystemPrompt: "Lost update."}, &stale, "", "")
+	assert.ErrorIs(t, err, service.ErrVersionConflict)
+	current, err := svc.GetByID(ctx, sp.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Report the order status.", current.SystemPrompt)
+
+	// Deleting a prompt frees its name, and restoring it fails once the
+	// name is taken again.
+	require.NoError(t, svc.Delete(ctx, sp.ID.String()))
+	other, err := svc.Create(ctx, "support", fields("order-status", "Replacement."), "", "")
+	require.NoError(t, err)
+	_, err = svc.Restore(ctx, sp.ID.String())
+	assert.ErrorIs(t, err, service.ErrPromptNameTaken)
+	_, err = svc.Update(ctx, other.ID.String(), service.PromptFields{Name: "order-status-v2"}, nil, "", "")
+	require.NoError(t, err)
+	_, err = svc.Restore(ctx, sp.ID.String())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+)
+
+// ErrInvalidPrompt wraps problems with a prompt's template or variable
+// declarations found when it is created or updated.
+var ErrInvalidPrompt = errors.New("invalid system prompt")
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PromptVariableError reports every problem found while binding /send
+// variables to a stored prompt's declarations.
+type PromptVariableError struct {
+	Missing []string `json:"missing,omitempty"`
+	Unknown []string `json:"unknown,omitempty"`
+	Invalid []string `json:"invalid,omitempty"`
+}
+
+func (e *PromptVariableError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required variables: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown variables: "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Invalid) > 0 {
+		parts = append(parts, "invalid variables: "+strings.Join(e.Invalid, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *PromptVariableError) empty() bool {
+	return len(e.Missing) == 0 && len(e.Unknown) == 0 && len(e.Invalid) == 0
+}
+
+// validateVariableDeclarations checks the declarations stored with a prompt
+// and that the prompt itself parses as a template.
+func validateVariableDeclarations(sys string, vars models.PromptVariables) error {
+	seen := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		if !variableNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("%w: invalid variable name %q", ErrInvalidPrompt, v.Name)
+		}
+		if v.Name == RetrievalContextVar {
+			return fmt.Errorf("%w: variable name %q is reserved for retrieval", ErrInvalidPrompt, v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("%w: variable %q declared twice", ErrInvalidPrompt, v.Name)
+		}
+		seen[v.Name] = true
+
+		switch v.Type {
+		case models.VariableTypeString, models.VariableTypeNumber, models.VariableTypeBoolean:
+		default:
+			return fmt.Errorf("%w: variable %q has unsupported type %q", ErrInvalidPrompt, v.Name, v.Type)
+		}
+		if v.Default != nil && !matchesVariableType(v.Type, v.Default) {
+			return fmt.Errorf("%w: default for variable %q is not a %s", ErrInvalidPrompt, v.Name, v.Type)
+		}
+	}
+
+	tmpl, err := template.New("system_prompt").Parse(sys)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+	}
+	var undeclared []string
+	for _, name := range templateReferences(tmpl) {
+		if !seen[name] && name != RetrievalContextVar {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		return fmt.Errorf("%w: template uses undeclared variables: %s", ErrInvalidPrompt, strings.Join(undeclared, ", "))
+	}
+	return nil
+}
+
+// templateReferences returns the sorted top-level names a template reads
+// from its data, such as name in {{.name}} or {{$.name.first}}. Fields
+// inside range and with blocks are relative to a new dot and are skipped,
+// though $ references there still count.
+func templateReferences(tmpl *template.Template) []string {
+	found := map[string]bool{}
+	var walk func(node parse.Node, atRoot bool)
+	walkPipe := func(pipe *parse.PipeNode, atRoot bool) {
+		if pipe != nil {
+			walk(pipe, atRoot)
+		}
+	}
+	walk = func(node parse.Node, atRoot bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c, atRoot)
+			}
+		case *parse.ActionNode:
+			walkPipe(n.Pipe, atRoot)
+		case *parse.PipeNode:
+			for _, cmd := range n.Cmds {
+				walk(cmd, atRoot)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, atRoot)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, atRoot)
+		case *parse.FieldNode:
+			if atRoot {
+				found[n.Ident[0]] = true
+			}
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				found[n.Ident[1]] = true
+			}
+		case *parse.IfNode:
+			walkPipe(n.Pipe, atRoot)
+			walk(n.List, atRoot)
+			walk(n.ElseList, atRoot)
+		case *parse.RangeNode:
+			walkPipe(n.Pipe, atRoot)
+			walk(n.List, false)
+			walk(n.ElseList, atRoot)
+		case *parse.WithNode:
+			walkPipe(n.Pipe, atRoot)
+			walk(n.List, false)
+			walk(n.ElseList, atRoot)
+		case *parse.TemplateNode:
+			walkPipe(n.Pipe, atRoot)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root, true)
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func matchesVariableType(typ string, value interface{}) bool {
+	switch typ {
+	case models.VariableTypeString:
+		_, ok := value.(string)
+		return ok
+	case models.VariableTypeNumber:
+		switch value.(type) {
+		case float64, float32, int, int64:
+			return true
+		}
+		return false
+	case models.VariableTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
+
+// bindVariables validates supplied values against the declarations and
+// fills in defaults.
+func bindVariables(decls models.PromptVariables, supplied map[string]interface{}) (map[string]interface{}, error) {
+	verr := &PromptVariableError{}
+	values := make(map[string]interface{}, len(decls))
+	declared := make(map[string]bool, len(decls))
+
+	for _, d := range decls {
+		declared[d.Name] = true
+		value, ok := supplied[d.Name]
+		switch {
+		case ok && value != nil:
+			if !matchesVariableType(d.Type, value) {
+				verr.Invalid = append(verr.Invalid, fmt.Sprintf("%s must be a %s", d.Name, d.Type))
+				continue
+			}
+			values[d.Name] = value
+		case d.Default != nil:
+			values[d.Name] = d.Default
+		case d.Required:
+			verr.Missing = append(verr.Missing, d.Name)
+		default:
+			values[d.Name] = zeroValue(d.Type)
+		}
+	}
+	for name := range supplied {
+		if !declared[name] {
+			verr.Unknown = append(verr.Unknown, name)
+		}
+	}
+
+	if !verr.empty() {
+		sort.Strings(verr.Missing)
+		sort.Strings(verr.Unknown)
+		sort.Strings(verr.Invalid)
+		return nil, verr
+	}
+	return values, nil
+}
+
+func zeroValue(typ string) interface{} {
+	switch typ {
+	case models.VariableTypeNumber:
+		return 0
+	case models.VariableTypeBoolean:
+		return false
+	}
+	return ""
+}
+
+// renderSystemPrompt executes sys as a template over values. References to
+// undeclared variables are errors rather than silently rendering as empty;
+// stored prompts are checked for them when saved, so at send time they come
+// from inline prompts and are the caller's mistake.
+func renderSystemPrompt(sys string, values map[string]interface{}) (string, error) {
+	tmpl, err := template.New("system_prompt").Option("missingkey=error").Parse(sys)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", fmt.Errorf("%w: rendering failed: %v", ErrInvalidPrompt, err)
+	}
+	return out.String(), nil
+}
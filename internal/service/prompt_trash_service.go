@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/audit"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+)
+
+// purgeBatch is how many expired prompts the scheduled purge loads per
+// query.
+const purgeBatch = 100
+
+// GetWithDeleted finds a prompt whether or not it is in the trash.
+func (s *SystemPromptService) GetWithDeleted(ctx context.Context, id string) (*models.SystemPrompt, error) {
+	return s.repo.GetWithDeleted(ctx, id)
+}
+
+// Restore brings a soft-deleted prompt back, along with its versions and
+// labels, which deleting it left in place.
+func (s *SystemPromptService) Restore(ctx context.Context, id string) (*models.SystemPrompt, error) {
+	var sp *models.SystemPrompt
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if sp, err = s.repo.GetWithDeleted(txCtx, id); err != nil {
+			return err
+		}
+		if !sp.DeletedAt.Valid {
+			return fmt.Errorf("%w: prompt is not deleted", ErrInvalidPrompt)
+		}
+		if err := checkEditable(txCtx, sp); err != nil {
+			return err
+		}
+		if err := s.checkNameFree(txCtx, sp); err != nil {
+			return err
+		}
+		before := snapshot(sp)
+		if err := s.repo.Restore(txCtx, id); err != nil {
+			return err
+		}
+		sp.DeletedAt.Valid = false
+		return s.audit.Record(txCtx, models.AuditActionRestore, models.AuditResourcePrompt, id, before, sp)
+	})
+	return sp, err
+}
+
+// Purge deletes a prompt for good, whether or not it is in the trash.
+func (s *SystemPromptService) Purge(ctx context.Context, id string) error {
+	return s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		sp, err := s.repo.GetWithDeleted(txCtx, id)
+		if err != nil {
+			return err
+		}
+		// Managed prompts leave the trash, but only their file removes
+		// them while they are live.
+		if !sp.DeletedAt.Valid {
+			if err := checkEditable(txCtx, sp); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.Purge(txCtx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionPurge, models.AuditResourcePrompt, id, sp, nil)
+	})
+}
+
+// PurgeDeleted purges the prompts of every tenant that were deleted more
+// than retention ago and returns how many it purged.
+func (s *SystemPromptService) PurgeDeleted(ctx context.Context, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+	ctx = audit.WithActor(ctx, audit.Actor{Name: "trash purge"})
+	done := 0
+	for {
+		expired, err := s.repo.ListDeletedBefore(ctx, cutoff, purgeBatch)
+		if err != nil || len(expired) == 0 {
+			return done, err
+		}
+		for _, sp := range expired {
+			if err := s.Purge(tenant.WithTenant(ctx, sp.TenantID), sp.ID.String()); err != nil {
+				return done, fmt.Errorf("failed to purge prompt %s: %w", sp.ID, err)
+			}
+			done++
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSystemPromptService_TrashRestoreAndPurge(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	auditSvc := service.NewAuditService(repository.NewAuditRepo(db))
+	ctx := context.Background()
+
+	kept, err := svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Be helpful."}, "", "")
+	require.NoError(t, err)
+	id := kept.ID.String()
+	_, err = svc.Update(ctx, id, service.PromptFields{SystemPrompt: "Be brief."}, nil, "", "")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(ctx, id))
+	_, err = svc.GetByID(ctx, id)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	deleted, err := svc.List(ctx, repository.PromptQuery{Deleted: true}, "")
+	require.NoError(t, err)
+	require.Len(t, deleted.Items, 1)
+	assert.True(t, deleted.Items[0].DeletedAt.Valid)
+
+	restored, err := svc.Restore(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	_, err = svc.Restore(ctx, id)
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt, "only deleted prompts can be restored")
+	versions, err := svc.ListVersions(ctx, id)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2, "history survives the trash")
+
+	acme := tenant.WithTenant(ctx, "acme")
+	expired, err := svc.Create(acme, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Old prompt."}, "", "")
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(acme, expired.ID.String()))
+
+	n, err := svc.PurgeDeleted(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, n, "recently deleted prompts are kept")
+	n, err = svc.PurgeDeleted(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = svc.GetWithDeleted(acme, expired.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	versions, err = svc.ListVersions(acme, expired.ID.String())
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	events, err := auditSvc.List(acme, repository.AuditFilter{Action: models.AuditActionPurge})
+	require.NoError(t, err)
+	require.Len(t, events, 1, "the purge is audited in the prompt's tenant")
+	assert.Equal(t, "trash purge", events[0].Actor)
+
+	require.NoError(t, svc.Purge(ctx, id))
+	_, err = svc.GetWithDeleted(ctx, id)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "live prompts can be purged directly")
+	assert.ErrorIs(t, svc.Purge(ctx, id), gorm.ErrRecordNotFound)
+}
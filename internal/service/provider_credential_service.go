@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/encryption"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCredential wraps validation failures when registering a
+// provider credential.
+var ErrInvalidCredential = errors.New("invalid provider credential")
+
+// reencryptBatch is how many credentials the re-encryption job reseals
+// per query.
+const reencryptBatch = 100
+
+type ProviderCredentialService struct {
+	repo      *repository.ProviderCredentialRepo
+	audit     *AuditService
+	providers *ProviderService
+	cfg       *config.Config
+}
+
+func NewProviderCredentialService(repo *repository.ProviderCredentialRepo, audit *AuditService, providers *ProviderService, cfg *config.Config) *ProviderCredentialService {
+	return &ProviderCredentialService{repo: repo, audit: audit, providers: providers, cfg: cfg}
+}
+
+func (s *ProviderCredentialService) keyring() (*encryption.Keyring, error) {
+	return encryption.NewKeyring(s.cfg.Security)
+}
+
+// credentialAAD binds a sealed key to the tenant, module and provider it
+// was registered for, so it cannot be copied onto another row.
+func credentialAAD(c *models.ProviderCredential) []byte {
+	return []byte(c.TenantID + "\x00" + c.ModuleName + "\x00" + c.Provider)
+}
+
+// Set registers apiKey for provider, replacing any previous key. An empty
+// module registers the tenant-wide key.
+func (s *ProviderCredentialService) Set(ctx context.Context, module, provider, apiKey string) (*models.ProviderCredential, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: api_key is required", ErrInvalidCredential)
+	}
+	current, err := s.providers.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if findProvider(current, provider) == nil {
+		return nil, fmt.Errorf("%w: provider %s is not configured", ErrInvalidCredential, provider)
+	}
+	keys, err := s.keyring()
+	if err != nil {
+		return nil, err
+	}
+
+	var c *models.ProviderCredential
+	err = s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		action := models.AuditActionUpdate
+		var before interface{}
+		c, err = s.repo.Find(txCtx, module, provider)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			action = models.AuditActionCreate
+			c = &models.ProviderCredential{TenantID: tenant.FromContext(ctx), ModuleName: module, Provider: provider}
+		case err != nil:
+			return err
+		default:
+			before = snapshot(c)
+		}
+		if c.SealedKey, err = keys.Seal([]byte(apiKey), credentialAAD(c)); err != nil {
+			return err
+		}
+		c.KeyVersion = keys.Version()
+		c.Hint = keyHint(apiKey)
+		if err := s.repo.Save(txCtx, c); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, action, models.AuditResourceCredential, c.ID.String(), before, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// keyHint shows the last four characters of keys long enough that doing
+// so gives little away.
+func keyHint(apiKey string) string {
+	if len(apiKey) < 12 {
+		return ""
+	}
+	return "..." + apiKey[len(apiKey)-4:]
+}
+
+func (s *ProviderCredentialService) Get(ctx context.Context, id string) (*models.ProviderCredential, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *ProviderCredentialService) List(ctx context.Context, module string) ([]models.ProviderCredential, error) {
+	return s.repo.List(ctx, module)
+}
+
+func (s *ProviderCredentialService) Delete(ctx context.Context, id string) error {
+	return s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		c, err := s.repo.Get(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(txCtx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionDelete, models.AuditResourceCredential, id, c, nil)
+	})
+}
+
+// Apply returns provider with the API key the caller's tenant registered
+// for module, falling back to its tenant-wide key and then to the
+// configured one. provider itself is never modified.
+func (s *ProviderCredentialService) Apply(ctx context.Context, module string, provider *config.ProviderConfig) (*config.ProviderConfig, error) {
+	c, err := s.repo.Find(ctx, module, provider.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) && module != "" {
+		c, err = s.repo.Find(ctx, "", provider.Name)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return provider, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.keyring()
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := keys.Open(c.SealedKey, credentialAAD(c))
+	if err != nil {
+		return nil, fmt.Errorf("provider credential %s: %w", c.ID, err)
+	}
+	withKey := *provider
+	withKey.APIKey = string(apiKey)
+	return &withKey, nil
+}
+
+// Reencrypt reseals every credential, across all tenants, that was sealed
+// with an older key version, and returns how many it resealed. Run it
+// after bumping the key version while the old key is still listed in
+// previous_keys.
+func (s *ProviderCredentialService) Reencrypt(ctx context.Context) (int, error) {
+	keys, err := s.keyring()
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for {
+		stale, err := s.repo.ListStale(ctx, keys.Version(), reencryptBatch)
+		if err != nil || len(stale) == 0 {
+			return done, err
+		}
+		for i := range stale {
+			c := &stale[i]
+			apiKey, err := keys.Open(c.SealedKey, credentialAAD(c))
+			if err != nil {
+				return done, fmt.Errorf("provider credential %s: %w", c.ID, err)
+			}
+			sealed, err := keys.Seal(apiKey, credentialAAD(c))
+			if err != nil {
+				return done, err
+			}
+			if err := s.repo.Reseal(ctx, c.ID.String(), c.SealedKey, sealed, keys.Version()); err != nil {
+				return done, err
+			}
+			done++
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderCredentialService_OverridesAndReencrypts(t *testing.T) {
+	fake := newFakeProvider(t)
+	var mu sync.Mutex
+	var lastAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastAuth = r.Header.Get("Authorization")
+		mu.Unlock()
+		fake.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(srv.URL)
+	cfg.Defaults.Providers[0].APIKey = "env-key"
+	cfg.Security = config.SecurityConfig{EncryptionKey: "0123456789abcdef0123456789abcdef", EncryptionKeyVersion: 1}
+	audit := service.NewAuditService(repository.NewAuditRepo(db))
+	creds := service.NewProviderCredentialService(repository.NewProviderCredentialRepo(db), audit,
+		service.NewProviderService(repository.NewProviderRepo(db), audit, cfg), cfg)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	_, err := creds.Set(acme, "", "openai", "acme-tenant-key-0001")
+	require.NoError(t, err)
+	supportKey, err := creds.Set(acme, "support", "openai", "acme-support-key-0002")
+	require.NoError(t, err)
+	assert.Equal(t, "...0002", supportKey.Hint)
+	assert.Equal(t, 1, supportKey.KeyVersion)
+	assert.NotContains(t, supportKey.SealedKey, "acme-support-key")
+
+	encoded, err := json.Marshal(supportKey)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), supportKey.SealedKey)
+
+	_, err = creds.Set(acme, "", "nope", "some-key-123456")
+	assert.ErrorIs(t, err, service.ErrInvalidCredential)
+
+	authFor := func(ctx context.Context, module string) string {
+		_, err := prompts.SendPrompt(ctx, service.SendRequest{
+			Module: module, SystemPrompt: "Be brief.", UserPrompt: "hi", BypassCache: true,
+		})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		return lastAuth
+	}
+	assert.Equal(t, "Bearer acme-support-key-0002", authFor(acme, "support"))
+	assert.Equal(t, "Bearer acme-tenant-key-0001", authFor(acme, "billing"))
+	assert.Equal(t, "Bearer env-key", authFor(globex, "support"))
+
+	cfg.Security = config.SecurityConfig{
+		EncryptionKey:        "fedcba9876543210fedcba9876543210",
+		EncryptionKeyVersion: 2,
+		PreviousKeys:         []config.PreviousKey{{Version: 1, Key: "0123456789abcdef0123456789abcdef"}},
+	}
+	assert.Equal(t, "Bearer acme-support-key-0002", authFor(acme, "support"), "old keys stay readable")
+
+	n, err := creds.Reencrypt(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = creds.Reencrypt(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	listed, err := creds.List(acme, "")
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	for _, c := range listed {
+		assert.Equal(t, 2, c.KeyVersion)
+	}
+
+	cfg.Security.PreviousKeys = nil
+	assert.Equal(t, "Bearer acme-support-key-0002", authFor(acme, "support"))
+}
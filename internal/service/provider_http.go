@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+)
+
+// postToProvider sends a JSON body to a provider endpoint, applying the
+// provider's auth method, and returns the raw response body.
+func postToProvider(ctx context.Context, provider *config.ProviderConfig, url string, body io.Reader) ([]byte, error) {
+	if provider.AuthMethod == "query_param" {
+		sep := "?"
+		if strings.Contains(url, "?") {
+			sep = "&"
+		}
+		url += fmt.Sprintf("%skey=%s", sep, provider.APIKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	if provider.AuthMethod == "header" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", provider.APIKey))
+	}
+
+	// Execute request
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Handle errors
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return responseBody, nil
+}
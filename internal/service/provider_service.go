@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidProvider wraps validation failures when registering a
+// provider or model.
+var ErrInvalidProvider = errors.New("invalid provider")
+
+// Where a provider or model is defined.
+const (
+	SourceConfig   = "config"
+	SourceDatabase = "database"
+	// SourceBoth marks a configured provider or model overridden by a
+	// database row.
+	SourceBoth = "both"
+)
+
+var (
+	// Provider names also name their <NAME>_API_KEY variable.
+	providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,49}$`)
+	modelNamePattern    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,99}$`)
+)
+
+// ProviderService manages the providers and models registered through
+// the API and resolves them together with the ones of the config file,
+// which act as the seed: a database row overrides the configured
+// provider or model of the same name, and everything else is added.
+type ProviderService struct {
+	repo  *repository.ProviderRepo
+	audit *AuditService
+	cfg   *config.Config
+}
+
+func NewProviderService(repo *repository.ProviderRepo, audit *AuditService, cfg *config.Config) *ProviderService {
+	return &ProviderService{repo: repo, audit: audit, cfg: cfg}
+}
+
+// ProviderFields are the settings of a provider that can be registered.
+type ProviderFields struct {
+	BaseURL        string `json:"base_url"`
+	AuthMethod     string `json:"auth_method"`
+	EmbeddingModel string `json:"embedding_model"`
+}
+
+// ModelFields are the settings of a model that can be registered.
+type ModelFields struct {
+	Parameters           string  `json:"parameters"`
+	Config               string  `json:"config"`
+	ResponsePath         string  `json:"response_path"`
+	PromptTokensPath     string  `json:"prompt_tokens_path"`
+	CompletionTokensPath string  `json:"completion_tokens_path"`
+	InputCostPer1K       float64 `json:"input_cost_per_1k"`
+	OutputCostPer1K      float64 `json:"output_cost_per_1k"`
+}
+
+func (f ModelFields) modelConfig(name string) *config.ModelConfig {
+	return &config.ModelConfig{
+		Name:                 name,
+		Parameters:           f.Parameters,
+		Config:               f.Config,
+		ResponsePath:         f.ResponsePath,
+		PromptTokensPath:     f.PromptTokensPath,
+		CompletionTokensPath: f.CompletionTokensPath,
+		InputCostPer1K:       f.InputCostPer1K,
+		OutputCostPer1K:      f.OutputCostPer1K,
+	}
+}
+
+// ProviderInfo describes a provider of the merged set. The API key itself
+// is never shown.
+type ProviderInfo struct {
+	Name string `json:"name"`
+	ProviderFields
+	Default   bool        `json:"default"`
+	HasAPIKey bool        `json:"has_api_key"`
+	Source    string      `json:"source"`
+	Models    []ModelInfo `json:"models"`
+}
+
+// ModelInfo describes a model of the merged set.
+type ModelInfo struct {
+	Name string `json:"name"`
+	ModelFields
+	Source string `json:"source"`
+}
+
+// Current returns the configured providers and models with the database
+// ones merged in. The default provider and model are those of the config
+// file. The result is a fresh copy that the caller may keep.
+func (s *ProviderService) Current(ctx context.Context) (*config.DefaultConfig, error) {
+	base := s.cfg.CurrentDefaults()
+	providers, err := s.repo.ListProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := s.repo.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mergeProviders(base, providers, ms), nil
+}
+
+func mergeProviders(base *config.DefaultConfig, providers []models.Provider, ms []models.Model) *config.DefaultConfig {
+	merged := &config.DefaultConfig{Provider: base.Provider, Model: base.Model}
+	for _, p := range base.Providers {
+		p.Models = append([]config.ModelConfig(nil), p.Models...)
+		merged.Providers = append(merged.Providers, p)
+	}
+	for _, row := range providers {
+		p := findProvider(merged, row.Name)
+		if p == nil {
+			merged.Providers = append(merged.Providers, config.ProviderConfig{
+				Name:   row.Name,
+				APIKey: os.Getenv(fmt.Sprintf("%s_API_KEY", strings.ToUpper(row.Name))),
+			})
+			p = &merged.Providers[len(merged.Providers)-1]
+		} else if p.BaseURL != row.BaseURL {
+			// The configured key was issued for the configured host; a
+			// provider moved elsewhere only gets tenants' own keys.
+			p.APIKey = ""
+		}
+		p.BaseURL = row.BaseURL
+		p.AuthMethod = row.AuthMethod
+		p.EmbeddingModel = row.EmbeddingModel
+	}
+	for _, row := range ms {
+		// Models of a provider since removed from the config file are
+		// kept until the provider comes back.
+		p := findProvider(merged, row.ProviderName)
+		if p == nil {
+			continue
+		}
+		m := config.ModelConfig{
+			Name:                 row.Name,
+			Parameters:           row.Parameters,
+			Config:               row.Config,
+			ResponsePath:         row.ResponsePath,
+			PromptTokensPath:     row.PromptTokensPath,
+			CompletionTokensPath: row.CompletionTokensPath,
+			InputCostPer1K:       row.InputCostPer1K,
+			OutputCostPer1K:      row.OutputCostPer1K,
+		}
+		if existing := findModel(p, row.Name); existing != nil {
+			*existing = m
+		} else {
+			p.Models = append(p.Models, m)
+		}
+	}
+	return merged
+}
+
+func findProvider(d *config.DefaultConfig, name string) *config.ProviderConfig {
+	for i := range d.Providers {
+		if d.Providers[i].Name == name {
+			return &d.Providers[i]
+		}
+	}
+	return nil
+}
+
+func findModel(p *config.ProviderConfig, name string) *config.ModelConfig {
+	for i := range p.Models {
+		if p.Models[i].Name == name {
+			return &p.Models[i]
+		}
+	}
+	return nil
+}
+
+// List returns the merged providers and where each one is defined.
+func (s *ProviderService) List(ctx context.Context) ([]ProviderInfo, error) {
+	current, err := s.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ListProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := s.repo.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]bool)
+	for _, p := range rows {
+		stored[p.Name] = true
+	}
+	for _, m := range ms {
+		stored[m.ProviderName+"/"+m.Name] = true
+	}
+	base := s.cfg.CurrentDefaults()
+
+	infos := make([]ProviderInfo, 0, len(current.Providers))
+	for _, p := range current.Providers {
+		configured := findProvider(base, p.Name)
+		info := ProviderInfo{
+			Name: p.Name,
+			ProviderFields: ProviderFields{
+				BaseURL:        p.BaseURL,
+				AuthMethod:     p.AuthMethod,
+				EmbeddingModel: p.EmbeddingModel,
+			},
+			Default:   p.Name == current.Provider,
+			HasAPIKey: p.APIKey != "",
+			Source:    source(configured != nil, stored[p.Name]),
+			Models:    make([]ModelInfo, 0, len(p.Models)),
+		}
+		for _, m := range p.Models {
+			info.Models = append(info.Models, ModelInfo{
+				Name: m.Name,
+				ModelFields: ModelFields{
+					Parameters:           m.Parameters,
+					Config:               m.Config,
+					ResponsePath:         m.ResponsePath,
+					PromptTokensPath:     m.PromptTokensPath,
+					CompletionTokensPath: m.CompletionTokensPath,
+					InputCostPer1K:       m.InputCostPer1K,
+					OutputCostPer1K:      m.OutputCostPer1K,
+				},
+				Source: source(configured != nil && findModel(configured, m.Name) != nil, stored[p.Name+"/"+m.Name]),
+			})
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func source(configured, stored bool) string {
+	switch {
+	case configured && stored:
+		return SourceBoth
+	case stored:
+		return SourceDatabase
+	default:
+		return SourceConfig
+	}
+}
+
+// Get returns the merged provider name.
+func (s *ProviderService) Get(ctx context.Context, name string) (*ProviderInfo, error) {
+	infos, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range infos {
+		if infos[i].Name == name {
+			return &infos[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// SaveProvider registers provider name, or replaces its registered
+// settings. A configured provider of the same name keeps its models and
+// takes the new settings; it keeps its API key only while its base URL
+// is unchanged.
+func (s *ProviderService) SaveProvider(ctx context.Context, name string, f ProviderFields) (*models.Provider, error) {
+	if !providerNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: name %q must be up to 50 lowercase letters, digits or '_'", ErrInvalidProvider, name)
+	}
+	if f.BaseURL == "" {
+		return nil, fmt.Errorf("%w: base_url is required", ErrInvalidProvider)
+	}
+	switch f.AuthMethod {
+	case "", "header", "query_param":
+	default:
+		return nil, fmt.Errorf("%w: auth_method must be header or query_param", ErrInvalidProvider)
+	}
+
+	var p *models.Provider
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		action := models.AuditActionUpdate
+		var before interface{}
+		p, err = s.repo.GetProvider(txCtx, name)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			action = models.AuditActionCreate
+			p = &models.Provider{Name: name}
+		case err != nil:
+			return err
+		default:
+			before = snapshot(p)
+		}
+		p.BaseURL, p.AuthMethod, p.EmbeddingModel = f.BaseURL, f.AuthMethod, f.EmbeddingModel
+		if err := s.repo.SaveProvider(txCtx, p); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, action, models.AuditResourceProvider, name, before, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DeleteProvider removes the registered provider name and its registered
+// models. A configured provider of the same name falls back to its
+// config file settings.
+func (s *ProviderService) DeleteProvider(ctx context.Context, name string) error {
+	return s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		p, err := s.repo.GetProvider(txCtx, name)
+		if err != nil {
+			return err
+		}
+		ms, err := s.repo.ListModels(txCtx)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteProvider(txCtx, name); err != nil {
+			return err
+		}
+		for i := range ms {
+			if ms[i].ProviderName != name {
+				continue
+			}
+			key := name + "/" + ms[i].Name
+			if err := s.audit.Record(txCtx, models.AuditActionDelete, models.AuditResourceModel, key, &ms[i], nil); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(txCtx, models.AuditActionDelete, models.AuditResourceProvider, name, p, nil)
+	})
+}
+
+// SaveModel registers model name of provider, or replaces its registered
+// settings. The provider may come from the config file or the database.
+func (s *ProviderService) SaveModel(ctx context.Context, provider, name string, f ModelFields) (*models.Model, error) {
+	if !modelNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: model name %q must be up to 100 letters, digits, '.', '_', ':' or '-'", ErrInvalidProvider, name)
+	}
+	if f.Config == "" {
+		return nil, fmt.Errorf("%w: config (the request template) is required", ErrInvalidProvider)
+	}
+	if _, problems := checkModel(f.modelConfig(name)); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProvider, strings.Join(problems, "; "))
+	}
+	if f.InputCostPer1K < 0 || f.OutputCostPer1K < 0 {
+		return nil, fmt.Errorf("%w: costs cannot be negative", ErrInvalidProvider)
+	}
+
+	var m *models.Model
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		current, err := s.Current(txCtx)
+		if err != nil {
+			return err
+		}
+		if findProvider(current, provider) == nil {
+			return fmt.Errorf("%w: provider %s is not configured", ErrInvalidProvider, provider)
+		}
+		action := models.AuditActionUpdate
+		var before interface{}
+		m, err = s.repo.GetModel(txCtx, provider, name)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			action = models.AuditActionCreate
+			m = &models.Model{ProviderName: provider, Name: name}
+		case err != nil:
+			return err
+		default:
+			before = snapshot(m)
+		}
+		m.Parameters, m.Config, m.ResponsePath = f.Parameters, f.Config, f.ResponsePath
+		m.PromptTokensPath, m.CompletionTokensPath = f.PromptTokensPath, f.CompletionTokensPath
+		m.InputCostPer1K, m.OutputCostPer1K = f.InputCostPer1K, f.OutputCostPer1K
+		if err := s.repo.SaveModel(txCtx, m); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, action, models.AuditResourceModel, provider+"/"+name, before, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeleteModel removes a registered model. A configured model of the same
+// name falls back to its config file settings.
+func (s *ProviderService) DeleteModel(ctx context.Context, provider, name string) error {
+	return s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		m, err := s.repo.GetModel(txCtx, provider, name)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteModel(txCtx, provider, name); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionDelete, models.AuditResourceModel, provider+"/"+name, m, nil)
+	})
+}
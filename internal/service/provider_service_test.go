@@ -0,0 +1,137 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestProviderService_MergesDatabaseProviders(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	url := newFakeProvider(t).URL
+	cfg := newTestConfig(url)
+	auditSvc := service.NewAuditService(repository.NewAuditRepo(db))
+	providers := service.NewProviderService(repository.NewProviderRepo(db), auditSvc, cfg)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+	send := func(provider, model string) (*models.AIUsageLog, error) {
+		return prompts.SendPrompt(ctx, service.SendRequest{
+			Module: "support", SystemPrompt: "Be brief.", UserPrompt: "hi " + provider + model,
+			Provider: provider, ModelName: model, BypassCache: true,
+		})
+	}
+	model := service.ModelFields{
+		Config:         `{"system": {{printf "%q" .SystemPrompt}}, "user": {{printf "%q" .UserPrompt}}}`,
+		ResponsePath:   "candidates.0.content.parts.0.text",
+		InputCostPer1K: 10,
+	}
+
+	_, err := send("local", "local-model")
+	require.Error(t, err, "unknown providers are rejected")
+
+	_, err = providers.SaveProvider(ctx, "local", service.ProviderFields{BaseURL: url + "/"})
+	require.NoError(t, err)
+	_, err = providers.SaveModel(ctx, "local", "local-model", model)
+	require.NoError(t, err)
+	out, err := send("local", "local-model")
+	require.NoError(t, err)
+	assert.Equal(t, "local", out.Provider)
+
+	// A stored model overrides the configured one of the same name.
+	_, err = providers.SaveModel(ctx, "openai", "fake-model", model)
+	require.NoError(t, err)
+	out, err = send("", "")
+	require.NoError(t, err)
+	overridden := out.Cost
+
+	list, err := providers.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, service.SourceConfig, list[0].Source)
+	assert.True(t, list[0].Default)
+	assert.Equal(t, service.SourceBoth, list[0].Models[0].Source)
+	assert.Equal(t, service.SourceDatabase, list[1].Source)
+
+	require.NoError(t, providers.DeleteModel(ctx, "openai", "fake-model"))
+	out, err = send("", "")
+	require.NoError(t, err)
+	assert.Less(t, out.Cost, overridden, "deleting the stored model restores the configured one")
+
+	require.NoError(t, providers.DeleteProvider(ctx, "local"))
+	_, err = send("local", "local-model")
+	assert.Error(t, err)
+
+	// Moving a configured provider to another host drops the operator's key.
+	cfg.Defaults.Providers[0].APIKey = "operator-key"
+	_, err = providers.SaveProvider(ctx, "openai", service.ProviderFields{BaseURL: url + "/", AuthMethod: "header"})
+	require.NoError(t, err)
+	info, err := providers.Get(ctx, "openai")
+	require.NoError(t, err)
+	assert.True(t, info.HasAPIKey)
+	_, err = providers.SaveProvider(ctx, "openai", service.ProviderFields{BaseURL: "http://elsewhere.invalid/"})
+	require.NoError(t, err)
+	info, err = providers.Get(ctx, "openai")
+	require.NoError(t, err)
+	assert.False(t, info.HasAPIKey)
+	require.NoError(t, providers.DeleteProvider(ctx, "openai"))
+
+	_, err = providers.SaveProvider(ctx, "Bad Name", service.ProviderFields{BaseURL: url})
+	assert.ErrorIs(t, err, service.ErrInvalidProvider)
+	_, err = providers.SaveModel(ctx, "missing", "m", model)
+	assert.ErrorIs(t, err, service.ErrInvalidProvider)
+	_, err = providers.SaveModel(ctx, "openai", "broken", service.ModelFields{Config: "{{.SystemPrompt", ResponsePath: "text"})
+	assert.ErrorIs(t, err, service.ErrInvalidProvider)
+
+	events, err := auditSvc.List(ctx, repository.AuditFilter{ResourceType: models.AuditResourceModel})
+	require.NoError(t, err)
+	assert.Len(t, events, 4)
+}
+
+func TestSystemPromptService_TestModel(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	url := newFakeProvider(t).URL
+	cfg := newTestConfig(url)
+	providers := service.NewProviderService(repository.NewProviderRepo(db),
+		service.NewAuditService(repository.NewAuditRepo(db)), cfg)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+
+	res, err := prompts.TestModel(ctx, "openai", "fake-model")
+	require.NoError(t, err)
+	assert.True(t, res.OK, res.Errors)
+	assert.NotEmpty(t, res.Text)
+	assert.Contains(t, res.Request, "connectivity check")
+
+	_, err = providers.SaveModel(ctx, "openai", "wrong-path", service.ModelFields{
+		Config:           cfg.Defaults.Providers[0].Models[0].Config,
+		ResponsePath:     "choices.0.text",
+		PromptTokensPath: "usage.prompt_tokens",
+	})
+	require.NoError(t, err)
+	res, err = prompts.TestModel(ctx, "openai", "wrong-path")
+	require.NoError(t, err)
+	assert.False(t, res.OK)
+	assert.NotEmpty(t, res.Response, "the raw response is shown when its text cannot be found")
+	require.Len(t, res.Errors, 2)
+	assert.Contains(t, res.Errors[0], "response_path")
+	assert.Contains(t, res.Errors[1], "prompt_tokens_path")
+
+	_, err = prompts.TestModel(ctx, "openai", "missing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, providers.CheckModels(ctx))
+	cfg.Defaults.Providers[0].Models = append(cfg.Defaults.Providers[0].Models,
+		config.ModelConfig{Name: "broken", Config: "{{.SystemPrompt", ResponsePath: "text..value"})
+	err = providers.CheckModels(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "openai/broken: invalid request template")
+	assert.Contains(t, err.Error(), `response_path "text..value" has an empty segment`)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+)
+
+const (
+	RedactionRedact = "redact"
+	RedactionMask   = "mask"
+	RedactionBlock  = "block"
+	RedactionOff    = "off"
+
+	EntityEmail      = "email"
+	EntityPhone      = "phone"
+	EntityCreditCard = "credit_card"
+)
+
+// ErrPIIBlocked is wrapped by PIIBlockedError.
+var ErrPIIBlocked = errors.New("request contains PII")
+
+// PIIBlockedError rejects a request whose module blocks PII, reporting
+// how many of each entity were found.
+type PIIBlockedError struct {
+	Entities map[string]int `json:"entities"`
+}
+
+func (e *PIIBlockedError) Error() string {
+	names := make([]string, 0, len(e.Entities))
+	for name, n := range e.Entities {
+		names = append(names, fmt.Sprintf("%s (%d)", name, n))
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%v: %s", ErrPIIBlocked, strings.Join(names, ", "))
+}
+
+func (e *PIIBlockedError) Unwrap() error { return ErrPIIBlocked }
+
+// detector finds one kind of entity. valid, when set, filters out regex
+// matches that are not really that entity.
+type detector struct {
+	entity string
+	re     *regexp.Regexp
+	valid  func(match string) bool
+	mask   func(match string) string
+}
+
+// Built-in detectors, in the order they run: card numbers before phone
+// numbers, which would otherwise claim their digits.
+var builtinDetectors = []detector{
+	{
+		entity: EntityCreditCard,
+		re:     regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		valid:  func(m string) bool { return luhnValid(digitsOf(m)) },
+		mask:   keepLastDigits(4),
+	},
+	{
+		entity: EntityEmail,
+		re:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		mask:   maskEmail,
+	},
+	{
+		entity: EntityPhone,
+		re:     regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{1,4}\)[ .-]?)?\d[\d .-]{5,}\d\b`),
+		valid: func(m string) bool {
+			n := len(digitsOf(m))
+			return n >= 7 && n <= 15 && !isoDate.MatchString(m)
+		},
+		mask: keepLastDigits(2),
+	},
+}
+
+var isoDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+var compiledPatterns sync.Map // regex source -> *regexp.Regexp
+
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := compiledPatterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	compiledPatterns.Store(expr, re)
+	return re, nil
+}
+
+// redactionFor returns the module's redaction session, or nil when
+// nothing is redacted for it. All redaction methods accept a nil session.
+func redactionFor(cfg config.RedactionConfig, module string) (*redaction, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	var policy *config.RedactionPolicy
+	for i := range cfg.Policies {
+		p := &cfg.Policies[i]
+		if p.Module == module {
+			policy = p
+			break
+		}
+		if p.Module == "" && policy == nil {
+			policy = p
+		}
+	}
+	if policy == nil || policy.Action == RedactionOff || policy.Action == "" {
+		return nil, nil
+	}
+
+	r := &redaction{
+		action:   policy.Action,
+		restore:  policy.RestoreResponse,
+		counts:   map[string]int{},
+		original: map[string]string{},
+		byValue:  map[string]string{},
+		next:     map[string]int{},
+	}
+	for _, d := range builtinDetectors {
+		if len(policy.Entities) == 0 || models.StringList(policy.Entities).Contains(d.entity) {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+	for _, p := range policy.Patterns {
+		re, err := compilePattern(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %s: %w", p.Name, err)
+		}
+		r.detectors = append(r.detectors, detector{entity: p.Name, re: re, mask: maskAll})
+	}
+	return r, nil
+}
+
+// redaction applies one module policy to the texts of a single request.
+// Equal values get the same placeholder, so the text stays coherent and
+// the placeholders can be restored in the response.
+type redaction struct {
+	action    string
+	restore   bool
+	detectors []detector
+	counts    map[string]int
+	original  map[string]string // placeholder -> value
+	byValue   map[string]string // value -> placeholder
+	next      map[string]int
+}
+
+// apply replaces every entity found in text and counts it.
+func (r *redaction) apply(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, d := range r.detectors {
+		text = d.re.ReplaceAllStringFunc(text, func(m string) string {
+			if d.valid != nil && !d.valid(m) {
+				return m
+			}
+			r.counts[d.entity]++
+			if r.action == RedactionMask {
+				return d.mask(m)
+			}
+			return r.placeholder(d.entity, m)
+		})
+	}
+	return text
+}
+
+func (r *redaction) placeholder(entity, value string) string {
+	if ph, ok := r.byValue[value]; ok {
+		return ph
+	}
+	r.next[entity]++
+	ph := fmt.Sprintf("[%s_%d]", strings.ToUpper(entity), r.next[entity])
+	r.byValue[value] = ph
+	r.original[ph] = value
+	return ph
+}
+
+// applyMap redacts the string values of a variables map, returning a copy.
+func (r *redaction) applyMap(vars map[string]interface{}) map[string]interface{} {
+	if r == nil || vars == nil {
+		return vars
+	}
+	out := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		if s, ok := v.(string); ok {
+			v = r.apply(s)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// check rejects the request when the policy blocks PII and some was found.
+func (r *redaction) check() error {
+	if r == nil || r.action != RedactionBlock || len(r.counts) == 0 {
+		return nil
+	}
+	return &PIIBlockedError{Entities: r.entities()}
+}
+
+// restoreText puts original values back in place of placeholders, when
+// the policy asks for it.
+func (r *redaction) restoreText(text string) string {
+	if r == nil || !r.restore || len(r.original) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(r.original))
+	for ph, v := range r.original {
+		pairs = append(pairs, ph, v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// entities returns a copy of the counts, or nil when nothing was found.
+func (r *redaction) entities() map[string]int {
+	if r == nil || len(r.counts) == 0 {
+		return nil
+	}
+	out := make(map[string]int, len(r.counts))
+	for k, v := range r.counts {
+		out[k] = v
+	}
+	return out
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func luhnValid(digits string) bool {
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// keepLastDigits masks every digit but the last n.
+func keepLastDigits(n int) func(string) string {
+	return func(m string) string {
+		hide := len(digitsOf(m)) - n
+		out := []byte(m)
+		for i, c := range out {
+			if c >= '0' && c <= '9' && hide > 0 {
+				out[i] = '*'
+				hide--
+			}
+		}
+		return string(out)
+	}
+}
+
+// maskEmail keeps the first character of the local part and the domain.
+func maskEmail(m string) string {
+	at := strings.LastIndexByte(m, '@')
+	if at <= 0 {
+		return maskAll(m)
+	}
+	return m[:1] + strings.Repeat("*", at-1) + m[at:]
+}
+
+func maskAll(m string) string {
+	return strings.Repeat("*", len([]rune(m)))
+}
@@ -0,0 +1,79 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendPrompt_RedactsPII(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	cfg.Redaction = config.RedactionConfig{
+		Enabled: true,
+		Policies: []config.RedactionPolicy{
+			{Action: service.RedactionRedact, RestoreResponse: true},
+			{Module: "marketing", Action: service.RedactionMask},
+			{Module: "billing", Action: service.RedactionBlock, Entities: []string{service.EntityCreditCard},
+				Patterns: []config.RedactionPattern{{Name: "account_id", Regex: `ACC-\d{8}`}}},
+			{Module: "internal", Action: service.RedactionOff},
+		},
+	}
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+	const pii = "Mail ada@example.com or call +1 (555) 123-4567 about card 4111 1111 1111 1111 by 2025-01-31."
+
+	sp, err := svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Customer: {{.email}}", Variables: models.PromptVariables{{Name: "email", Type: models.VariableTypeString, Required: true}}}, "", "")
+	require.NoError(t, err)
+	out, err := svc.SendPrompt(ctx, service.SendRequest{
+		Module: "support", PromptID: sp.ID.String(), UserPrompt: pii,
+		Variables: map[string]interface{}{"email": "ada@example.com"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out.Response, "ada@example.com", "placeholders are restored for the caller")
+	assert.Equal(t, models.EntityCounts{"email": 2, "phone": 1, "credit_card": 1}, out.Redactions)
+
+	var logged models.AIUsageLog
+	require.NoError(t, db.First(&logged, "id = ?", out.ID).Error)
+	for _, stored := range []string{logged.Request, logged.Response, logged.UserPrompt} {
+		assert.NotContains(t, stored, "ada@example.com")
+		assert.NotContains(t, stored, "123-4567")
+		assert.NotContains(t, stored, "4111")
+	}
+	assert.Contains(t, logged.Request, "Customer: [EMAIL_1]", "equal values share a placeholder")
+	assert.Contains(t, logged.Response, "[CREDIT_CARD_1]", "the provider only saw placeholders")
+	assert.Contains(t, logged.Request, "2025-01-31", "dates are not phone numbers")
+	assert.Equal(t, "[EMAIL_1]", logged.Variables["email"])
+
+	masked, err := svc.SendPrompt(ctx, service.SendRequest{Module: "marketing", SystemPrompt: "Be brief.", UserPrompt: pii})
+	require.NoError(t, err)
+	assert.Contains(t, masked.Response, "a**@example.com")
+	assert.Contains(t, masked.Response, "**** **** **** 1111")
+	assert.NotContains(t, masked.Response, "[EMAIL_1]")
+
+	for _, userPrompt := range []string{"Refund 4111-1111-1111-1111", "Close ACC-12345678"} {
+		_, err = svc.SendPrompt(ctx, service.SendRequest{Module: "billing", SystemPrompt: "Be brief.", UserPrompt: userPrompt})
+		var blocked *service.PIIBlockedError
+		require.ErrorAs(t, err, &blocked, userPrompt)
+		assert.ErrorIs(t, err, service.ErrPIIBlocked)
+		assert.Len(t, blocked.Entities, 1)
+	}
+	_, err = svc.SendPrompt(ctx, service.SendRequest{Module: "billing", SystemPrompt: "Be brief.", UserPrompt: "mail ada@example.com"})
+	assert.NoError(t, err, "billing only blocks the entities it lists")
+
+	var count int64
+	require.NoError(t, db.Model(&models.AIUsageLog{}).Where("module_name = ?", "billing").Count(&count).Error)
+	assert.Equal(t, int64(1), count, "blocked requests never reach the provider")
+
+	open, err := svc.SendPrompt(ctx, service.SendRequest{Module: "internal", SystemPrompt: "Be brief.", UserPrompt: pii})
+	require.NoError(t, err)
+	assert.Contains(t, open.Response, "4111 1111 1111 1111")
+	assert.Nil(t, open.Redactions)
+}
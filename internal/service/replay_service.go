@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+)
+
+// ErrInvalidReplay wraps validation failures when creating a replay job.
+var ErrInvalidReplay = errors.New("invalid replay")
+
+const (
+	defaultReplaySampleSize = 50
+	maxReplaySampleSize     = 1000
+)
+
+type ReplayService struct {
+	repo    *repository.ReplayRepo
+	prompts *SystemPromptService
+}
+
+func NewReplayService(repo *repository.ReplayRepo, prompts *SystemPromptService) *ReplayService {
+	return &ReplayService{repo: repo, prompts: prompts}
+}
+
+type ReplaySummary struct {
+	Total                int     `json:"total"`
+	Changed              int     `json:"changed"`
+	Unchanged            int     `json:"unchanged"`
+	Failed               int     `json:"failed"`
+	AvgOriginalLatencyMs float64 `json:"avg_original_latency_ms"`
+	AvgReplayLatencyMs   float64 `json:"avg_replay_latency_ms"`
+	OriginalCost         float64 `json:"original_cost"`
+	ReplayCost           float64 `json:"replay_cost"`
+}
+
+type ReplayDiff struct {
+	UsageLogID       string     `json:"usage_log_id"`
+	UserPrompt       string     `json:"user_prompt"`
+	OriginalResponse string     `json:"original_response"`
+	ReplayResponse   string     `json:"replay_response"`
+	Changed          bool       `json:"changed"`
+	Error            string     `json:"error,omitempty"`
+	Diff             []DiffLine `json:"diff,omitempty"`
+}
+
+// ReplayReport lists every replayed request with a line diff of the two
+// responses, changed ones first.
+type ReplayReport struct {
+	Job     *models.ReplayJob `json:"job"`
+	Summary ReplaySummary     `json:"summary"`
+	Items   []ReplayDiff      `json:"items"`
+}
+
+// CreateJob validates and stores a pending job. Execute runs it.
+func (s *ReplayService) CreateJob(ctx context.Context, job *models.ReplayJob) (*models.ReplayJob, error) {
+	if job.ModuleName == "" {
+		return nil, fmt.Errorf("%w: module is required", ErrInvalidReplay)
+	}
+	if job.From != nil && job.To != nil && !job.From.Before(*job.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidReplay)
+	}
+	if job.PromptVersionID == nil && job.Provider == "" {
+		return nil, fmt.Errorf("%w: choose a prompt version, a model or both", ErrInvalidReplay)
+	}
+	if job.PromptVersionID != nil {
+		version, err := s.prompts.repo.GetVersionByID(ctx, job.PromptVersionID.String())
+		if err != nil {
+			return nil, fmt.Errorf("%w: prompt version not found", ErrInvalidReplay)
+		}
+		if version.ModuleName != job.ModuleName {
+			return nil, fmt.Errorf("%w: prompt version belongs to module %s", ErrInvalidReplay, version.ModuleName)
+		}
+	}
+	if (job.Provider == "") != (job.ModelName == "") {
+		return nil, fmt.Errorf("%w: provider and model_name must be given together", ErrInvalidReplay)
+	}
+	if job.Provider != "" {
+		if _, _, err := s.prompts.findProviderAndModel(ctx, job.Provider, job.ModelName); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidReplay, err)
+		}
+	}
+
+	switch {
+	case job.SampleSize <= 0:
+		job.SampleSize = defaultReplaySampleSize
+	case job.SampleSize > maxReplaySampleSize:
+		job.SampleSize = maxReplaySampleSize
+	}
+	switch {
+	case job.Concurrency <= 0:
+		job.Concurrency = defaultEvalConcurrency
+	case job.Concurrency > maxEvalConcurrency:
+		job.Concurrency = maxEvalConcurrency
+	}
+	job.Status = models.ReplayPending
+	err := s.repo.CreateJob(ctx, job)
+	return job, err
+}
+
+// StartJob creates the job and executes it in the background.
+func (s *ReplayService) StartJob(ctx context.Context, job *models.ReplayJob) (*models.ReplayJob, error) {
+	created, err := s.CreateJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	go s.Execute(tenant.Detach(ctx), created.ID.String())
+	return created, nil
+}
+
+// Execute samples the job's traffic and re-sends every request with the
+// cache bypassed, storing both responses side by side.
+func (s *ReplayService) Execute(ctx context.Context, jobID string) (*models.ReplayJob, error) {
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.ReplayPending {
+		return nil, fmt.Errorf("%w: job is %s", ErrInvalidReplay, job.Status)
+	}
+
+	logs, err := s.repo.SampleLogs(ctx, repository.UsageFilter{
+		Module: job.ModuleName, From: job.From, To: job.To,
+	}, job.SampleSize)
+	if err != nil {
+		return nil, s.failJob(ctx, job, err)
+	}
+
+	now := time.Now()
+	job.Status, job.StartedAt, job.Total = models.ReplayRunning, &now, len(logs)
+	if err := s.repo.SaveJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	var storeErr error
+	runConcurrently(job.Concurrency, logs,
+		func(l models.AIUsageLog) *models.ReplayResult {
+			return s.replay(ctx, job, &l)
+		},
+		func(result *models.ReplayResult) {
+			switch {
+			case result.Error != "":
+				job.Failed++
+			case result.Changed:
+				job.Changed++
+			}
+			if err := s.repo.CreateResult(ctx, result); err != nil && storeErr == nil {
+				storeErr = err
+			}
+		})
+	if storeErr != nil {
+		return nil, s.failJob(ctx, job, storeErr)
+	}
+
+	finished := time.Now()
+	job.Status, job.FinishedAt = models.ReplayCompleted, &finished
+	if err := s.repo.SaveJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *ReplayService) replay(ctx context.Context, job *models.ReplayJob, orig *models.AIUsageLog) *models.ReplayResult {
+	result := &models.ReplayResult{
+		JobID:             job.ID,
+		UsageLogID:        orig.ID,
+		UserPrompt:        orig.UserPrompt,
+		OriginalResponse:  orig.Response,
+		OriginalLatencyMs: orig.LatencyMs,
+		OriginalCost:      orig.Cost,
+	}
+
+	req := SendRequest{
+		Module:      job.ModuleName,
+		UserPrompt:  orig.UserPrompt,
+		Provider:    orig.Provider,
+		ModelName:   orig.ModelName,
+		BypassCache: true,
+		Source:      models.UsageSourceReplay,
+	}
+	if job.Provider != "" {
+		req.Provider, req.ModelName = job.Provider, job.ModelName
+	}
+	if job.PromptVersionID != nil {
+		req.VersionID = job.PromptVersionID.String()
+		req.Variables = orig.Variables
+	} else {
+		// Request is the rendered system prompt and the user prompt joined
+		// by a newline; reuse the system part verbatim.
+		req.SystemPrompt = strings.TrimSuffix(orig.Request, "\n"+orig.UserPrompt)
+	}
+
+	out, err := s.prompts.SendPrompt(ctx, req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ReplayLogID = &out.ID
+	result.ReplayResponse = out.Response
+	result.ReplayLatencyMs = out.LatencyMs
+	result.ReplayCost = out.Cost
+	result.Changed = out.Response != orig.Response
+	return result
+}
+
+func (s *ReplayService) failJob(ctx context.Context, job *models.ReplayJob, cause error) error {
+	finished := time.Now()
+	job.Status, job.Error, job.FinishedAt = models.ReplayFailed, cause.Error(), &finished
+	if err := s.repo.SaveJob(ctx, job); err != nil {
+		return err
+	}
+	return cause
+}
+
+func (s *ReplayService) GetJob(ctx context.Context, id string) (*models.ReplayJob, error) {
+	return s.repo.GetJob(ctx, id)
+}
+
+func (s *ReplayService) ListJobs(ctx context.Context, module string) ([]models.ReplayJob, error) {
+	return s.repo.ListJobs(ctx, module)
+}
+
+func (s *ReplayService) Report(ctx context.Context, jobID string) (*ReplayReport, error) {
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.repo.ListResults(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{Job: job}
+	var changed, rest []ReplayDiff
+	var origLatency, replayLatency int64
+	for _, r := range results {
+		item := ReplayDiff{
+			UsageLogID:       r.UsageLogID.String(),
+			UserPrompt:       r.UserPrompt,
+			OriginalResponse: r.OriginalResponse,
+			ReplayResponse:   r.ReplayResponse,
+			Changed:          r.Changed,
+			Error:            r.Error,
+		}
+		report.Summary.Total++
+		report.Summary.OriginalCost += r.OriginalCost
+		origLatency += r.OriginalLatencyMs
+		switch {
+		case r.Error != "":
+			report.Summary.Failed++
+			rest = append(rest, item)
+			continue
+		case r.Changed:
+			report.Summary.Changed++
+			item.Diff = diffLines(r.OriginalResponse, r.ReplayResponse)
+			changed = append(changed, item)
+		default:
+			report.Summary.Unchanged++
+			rest = append(rest, item)
+		}
+		report.Summary.ReplayCost += r.ReplayCost
+		replayLatency += r.ReplayLatencyMs
+	}
+	if n := report.Summary.Total; n > 0 {
+		report.Summary.AvgOriginalLatencyMs = float64(origLatency) / float64(n)
+	}
+	if n := report.Summary.Changed + report.Summary.Unchanged; n > 0 {
+		report.Summary.AvgReplayLatencyMs = float64(replayLatency) / float64(n)
+	}
+	report.Items = append(changed, rest...)
+	return report, nil
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayService_ReplaysLiveTrafficAgainstNewVersion(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	replays := service.NewReplayService(repository.NewReplayRepo(db), prompts)
+	ctx := context.Background()
+
+	vars := models.PromptVariables{{Name: "tone", Type: models.VariableTypeString, Required: true}}
+	sp, err := prompts.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Be {{.tone}}.", Variables: vars}, "", "")
+	require.NoError(t, err)
+	for _, user := range []string{"a", "b", "c"} {
+		_, err := prompts.SendPrompt(ctx, service.SendRequest{
+			Module: "support", PromptID: sp.ID.String(), UserPrompt: user,
+			Variables: map[string]interface{}{"tone": "brief"},
+		})
+		require.NoError(t, err)
+	}
+	_, err = prompts.SendPrompt(ctx, service.SendRequest{
+		Module: "support", SystemPrompt: "eval traffic", UserPrompt: "d", Source: models.UsageSourceEval,
+	})
+	require.NoError(t, err)
+
+	_, err = replays.CreateJob(ctx, &models.ReplayJob{ModuleName: "support"})
+	assert.ErrorIs(t, err, service.ErrInvalidReplay, "a target version or model is required")
+
+	_, err = prompts.Update(ctx, sp.ID.String(), service.PromptFields{SystemPrompt: "Be {{.tone}} and kind.", Variables: vars}, nil, "", "")
+	require.NoError(t, err)
+	updated, err := repository.NewSystemPromptRepo(db).GetByID(ctx, sp.ID.String())
+	require.NoError(t, err)
+
+	job, err := replays.CreateJob(ctx, &models.ReplayJob{
+		ModuleName: "support", PromptVersionID: updated.CurrentVersionID, Concurrency: 2,
+	})
+	require.NoError(t, err)
+	job, err = replays.Execute(ctx, job.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, models.ReplayCompleted, job.Status)
+	assert.Equal(t, 3, job.Total, "only live completions are sampled")
+	assert.Equal(t, 3, job.Changed)
+
+	report, err := replays.Report(ctx, job.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Summary.Changed)
+	require.Len(t, report.Items, 3)
+	item := report.Items[0]
+	assert.Contains(t, item.OriginalResponse, "Be brief.")
+	assert.Contains(t, item.ReplayResponse, "Be brief and kind.")
+	assert.NotEmpty(t, item.Diff)
+
+	// Same prompt, same model: the echo provider reproduces every response.
+	job, err = replays.CreateJob(ctx, &models.ReplayJob{
+		ModuleName: "support", Provider: "openai", ModelName: "fake-model", SampleSize: 2,
+	})
+	require.NoError(t, err)
+	job, err = replays.Execute(ctx, job.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, 0, job.Changed)
+	assert.Equal(t, 0, job.Failed)
+}
@@ -8,8 +8,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strconv"
 	"strings"
 	"text/template"
@@ -18,17 +16,66 @@ import (
 	"github.com/abeselom-personal/go-ai-service/internal/config"
 	models "github.com/abeselom-personal/go-ai-service/internal/model"
 	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type SystemPromptService struct {
-	repo *repository.SystemPromptRepo
-	db   *gorm.DB
-	cfg  *config.Config
+	repo        *repository.SystemPromptRepo
+	experiments *repository.ExperimentRepo
+	rateLimits  *repository.RateLimitRepo
+	credentials *ProviderCredentialService
+	providers   *ProviderService
+	audit       *AuditService
+	db          *gorm.DB
+	cfg         *config.Config
+	docs        *DocumentService
 }
 
-func NewSystemPromptService(db *gorm.DB, repo *repository.SystemPromptRepo, cfg *config.Config) *SystemPromptService {
-	return &SystemPromptService{repo: repo, db: db, cfg: cfg}
+func NewSystemPromptService(db *gorm.DB, repo *repository.SystemPromptRepo, cfg *config.Config, docs *DocumentService) *SystemPromptService {
+	audit := NewAuditService(repository.NewAuditRepo(db))
+	providers := NewProviderService(repository.NewProviderRepo(db), audit, cfg)
+	return &SystemPromptService{
+		repo:        repo,
+		experiments: repository.NewExperimentRepo(db),
+		rateLimits:  repository.NewRateLimitRepo(db),
+		credentials: NewProviderCredentialService(repository.NewProviderCredentialRepo(db), audit, providers, cfg),
+		providers:   providers,
+		audit:       audit,
+		db:          db,
+		cfg:         cfg,
+		docs:        docs,
+	}
+}
+
+// SendRequest carries the inputs of a single /send call.
+type SendRequest struct {
+	Module       string
+	SystemPrompt string
+	UserPrompt   string
+	BypassCache  bool
+	// PromptID selects a stored prompt instead of SystemPrompt. Its text is
+	// rendered server-side with Variables bound to its declarations.
+	PromptID string
+	// Label selects the version a label such as "production" points at,
+	// either on PromptID or on the module's only prompt carrying it.
+	Label     string
+	Variables map[string]interface{}
+	// SubjectID is a caller-chosen stable ID (user, session, ...) used to
+	// assign the request to a variant of the module's running experiment.
+	SubjectID string
+	// Retrieval, when set, injects the top-k chunks of a document
+	// collection into the system prompt before it is hashed and sent.
+	Retrieval *RetrievalOptions
+	// VersionID pins an exact prompt version, taking precedence over
+	// PromptID and Label. Provider and ModelName override the active
+	// model; both must be set together.
+	VersionID string
+	Provider  string
+	ModelName string
+	// Source is recorded on the usage log; empty for live traffic.
+	Source string
 }
 
 func hashPrompt(systemPrompt, userPrompt, moduleName string) string {
@@ -37,15 +84,49 @@ func hashPrompt(systemPrompt, userPrompt, moduleName string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func (s *SystemPromptService) Create(ctx context.Context, module, provider, sys, modelname string) (*models.SystemPrompt, error) {
+// ErrVersionConflict is returned by conditional updates of a prompt that
+// changed since the caller read it.
+var ErrVersionConflict = errors.New("prompt was modified concurrently")
+
+// PromptFields are the editable fields of a system prompt.
+type PromptFields struct {
+	Name         string
+	Provider     string
+	ModelName    string
+	SystemPrompt string
+	Variables    models.PromptVariables
+}
+
+func (s *SystemPromptService) Create(ctx context.Context, module string, f PromptFields, author, note string) (*models.SystemPrompt, error) {
+	if err := validatePromptName(f.Name); err != nil {
+		return nil, err
+	}
+	if err := validateVariableDeclarations(f.SystemPrompt, f.Variables); err != nil {
+		return nil, err
+	}
 
 	sp := &models.SystemPrompt{
 		ModuleName:   module,
-		ModelName:    modelname,
-		Provider:     provider,
-		SystemPrompt: sys,
+		Name:         f.Name,
+		ModelName:    f.ModelName,
+		Provider:     f.Provider,
+		SystemPrompt: f.SystemPrompt,
+		Variables:    f.Variables,
+		// Prompts created by the file sync belong to their file.
+		ConfigManaged: fromPromptFiles(ctx),
 	}
-	err := s.repo.Create(ctx, sp)
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.checkNameFree(txCtx, sp); err != nil {
+			return err
+		}
+		if err := s.repo.Create(txCtx, sp); err != nil {
+			return err
+		}
+		if err := s.recordVersion(txCtx, sp, author, note); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionCreate, models.AuditResourcePrompt, sp.ID.String(), nil, sp)
+	})
 	return sp, err
 }
 
@@ -53,26 +134,96 @@ func (s *SystemPromptService) Get(ctx context.Context) ([]models.SystemPrompt, e
 	return s.repo.List(ctx)
 }
 
-func (s *SystemPromptService) GetHash(ctx context.Context, hash string) (*models.SystemPrompt, error) {
-	return s.repo.GetByHash(ctx, hash)
+func (s *SystemPromptService) GetByID(ctx context.Context, id string) (*models.SystemPrompt, error) {
+	return s.repo.GetByID(ctx, id)
 }
-func (s *SystemPromptService) Update(ctx context.Context, id string, sys, user string) error {
-	var sp models.SystemPrompt
-	if err := s.db.WithContext(ctx).First(&sp, "id = ?", id).Error; err != nil {
-		return err
+
+// GetByName finds the live prompt of module with the given name.
+func (s *SystemPromptService) GetByName(ctx context.Context, module, name string) (*models.SystemPrompt, error) {
+	return s.repo.GetByName(ctx, module, name)
+}
+
+// Update replaces the prompt's fields and records the edit as a new
+// version. Empty strings and nil Variables keep the current values. When
+// ifVersion is set the update only goes through while the prompt is still
+// at that version, and fails with ErrVersionConflict otherwise.
+func (s *SystemPromptService) Update(ctx context.Context, id string, f PromptFields, ifVersion *int, author, note string) (*models.SystemPrompt, error) {
+	if err := validatePromptName(f.Name); err != nil {
+		return nil, err
 	}
-	sp.SystemPrompt = sys
-	return s.repo.Update(ctx, &sp)
+
+	var sp *models.SystemPrompt
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if sp, err = s.repo.GetByIDForUpdate(txCtx, id); err != nil {
+			return err
+		}
+		if err := checkEditable(txCtx, sp); err != nil {
+			return err
+		}
+		if ifVersion != nil && *ifVersion != sp.Version {
+			return fmt.Errorf("%w: it is at version %d, not %d", ErrVersionConflict, sp.Version, *ifVersion)
+		}
+		before := snapshot(sp)
+		// Prompts created before versioning get their current text
+		// preserved as version 1 before it is overwritten.
+		if sp.Version == 0 {
+			if err := s.recordVersion(txCtx, sp, "", "initial version"); err != nil {
+				return err
+			}
+		}
+
+		for dst, v := range map[*string]string{
+			&sp.Name: f.Name, &sp.Provider: f.Provider, &sp.ModelName: f.ModelName, &sp.SystemPrompt: f.SystemPrompt,
+		} {
+			if v != "" {
+				*dst = v
+			}
+		}
+		if f.Variables != nil {
+			sp.Variables = f.Variables
+		}
+		if fromPromptFiles(txCtx) {
+			sp.ConfigManaged = true
+		}
+		if err := validateVariableDeclarations(sp.SystemPrompt, sp.Variables); err != nil {
+			return err
+		}
+		if err := s.checkNameFree(txCtx, sp); err != nil {
+			return err
+		}
+		if err := s.recordVersion(txCtx, sp, author, note); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionUpdate, models.AuditResourcePrompt, id, before, sp)
+	})
+	return sp, err
 }
 
 func (s *SystemPromptService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	return s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		sp, err := s.repo.GetByID(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkEditable(txCtx, sp); err != nil {
+			return err
+		}
+		if err := s.repo.Delete(txCtx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionDelete, models.AuditResourcePrompt, id, sp, nil)
+	})
 }
 
-func (s *SystemPromptService) getActiveProviderAndModel() (*config.ProviderConfig, *config.ModelConfig, error) {
-	activeModel := s.cfg.Defaults.Model
-	for i := range s.cfg.Defaults.Providers {
-		provider := &s.cfg.Defaults.Providers[i]
+func (s *SystemPromptService) getActiveProviderAndModel(ctx context.Context) (*config.ProviderConfig, *config.ModelConfig, error) {
+	defaults, err := s.providers.Current(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	activeModel := defaults.Model
+	for i := range defaults.Providers {
+		provider := &defaults.Providers[i]
 		for j := range provider.Models {
 			model := &provider.Models[j]
 			if model.Name == activeModel {
@@ -83,26 +234,146 @@ func (s *SystemPromptService) getActiveProviderAndModel() (*config.ProviderConfi
 	return nil, nil, errors.New("active model not found in any provider")
 }
 
-func (s *SystemPromptService) SendPrompt(
-	ctx context.Context,
-	module,
-	sys,
-	user string,
-	bypassCache bool,
-) (*models.AIUsageLog, error) {
-	hash := hashPrompt(sys, user, module)
+func (s *SystemPromptService) findProviderAndModel(ctx context.Context, providerName, modelName string) (*config.ProviderConfig, *config.ModelConfig, error) {
+	defaults, err := s.providers.Current(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range defaults.Providers {
+		provider := &defaults.Providers[i]
+		if provider.Name != providerName {
+			continue
+		}
+		for j := range provider.Models {
+			model := &provider.Models[j]
+			if model.Name == modelName {
+				return provider, model, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("model %s not found for provider %s", modelName, providerName)
+}
+
+func (s *SystemPromptService) SendPrompt(ctx context.Context, req SendRequest) (*models.AIUsageLog, error) {
+	module := req.Module
+
+	// A label is resolved once, so a promotion racing with this request
+	// cannot pick the experiment for one version and send another.
+	pinned := req.VersionID != ""
+	promptID := req.PromptID
+	var labelled *models.SystemPromptVersion
+	if req.Label != "" && !pinned {
+		var err error
+		if labelled, err = s.resolveLabel(ctx, module, req.PromptID, req.Label); err != nil {
+			return nil, err
+		}
+		promptID = labelled.SystemPromptID.String()
+	}
+
+	// Traffic with a subject ID for the prompt of the module's running
+	// experiment is split between its variants. Pinned versions and inline
+	// prompts are never taken over.
+	var variant *models.ExperimentVariant
+	if req.SubjectID != "" && !pinned && promptID != "" {
+		var err error
+		if variant, err = s.assignVariant(ctx, module, promptID, req.SubjectID); err != nil {
+			return nil, err
+		}
+	}
+	if variant != nil {
+		req.VersionID = variant.PromptVersionID.String()
+		req.Provider, req.ModelName = variant.Provider, variant.ModelName
+	}
+
+	// PII is redacted before anything is rendered, hashed, sent to the
+	// provider or logged. Inputs go first so values substituted into the
+	// prompt are only counted once.
+	redact, err := redactionFor(s.cfg.Redaction, module)
+	if err != nil {
+		return nil, err
+	}
+	req.UserPrompt = redact.apply(req.UserPrompt)
+	req.Variables = redact.applyMap(req.Variables)
+	user := req.UserPrompt
+
+	sys, versionID, err := s.resolveSystemPrompt(ctx, req, labelled)
+	if err != nil {
+		if variant != nil {
+			return nil, fmt.Errorf("experiment variant %s: %w", variant.Name, err)
+		}
+		return nil, err
+	}
+	sys = redact.apply(sys)
+	if err := redact.check(); err != nil {
+		return nil, err
+	}
+
+	var provider *config.ProviderConfig
+	var model *config.ModelConfig
+	if req.Provider != "" {
+		provider, model, err = s.findProviderAndModel(ctx, req.Provider, req.ModelName)
+	} else {
+		provider, model, err = s.getActiveProviderAndModel(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Input guardrails see the user prompt as it will be sent; a rewrite
+	// changes what is hashed and cached.
+	guard, err := guardrailFor(s.cfg.Guardrail, module)
+	if err != nil {
+		return nil, err
+	}
+	user, inputHits, guardErr := s.checkGuardrail(ctx, guard, GuardrailStageInput, user)
+
+	// The hash covers the rendered prompt so different variables or
+	// retrieved context never share a cache entry. Experiment variants
+	// and model overrides cache separately since they may differ only
+	// by model.
+	cacheScope := module
+	if variant != nil {
+		cacheScope += ":" + variant.ID.String()
+	} else if req.Provider != "" {
+		cacheScope += ":" + req.Provider + "/" + req.ModelName
+	}
+	hash := hashPrompt(sys, user, cacheScope)
+
+	logEntry := &models.AIUsageLog{
+		ModuleName:    module,
+		Provider:      provider.Name,
+		ModelName:     model.Name,
+		Kind:          models.UsageKindCompletion,
+		PromptHash:    hash,
+		Request:       sys + "\n" + user, // Store combined request
+		UserPrompt:    user,
+		Variables:     req.Variables,
+		Source:        req.Source,
+		Redactions:    redact.entities(),
+		GuardrailHits: inputHits,
+		Status:        models.UsageStatusOK,
+
+		PromptVersionID: versionID,
+	}
+	if variant != nil {
+		logEntry.ExperimentID = &variant.ExperimentID
+		logEntry.VariantID = &variant.ID
+	}
+	if guardErr != nil {
+		return nil, s.logRejected(ctx, logEntry, guardErr)
+	}
 
 	// Check cache first unless bypass is requested
-	if !bypassCache {
+	if !req.BypassCache {
 		cached, err := s.getCachedResponse(ctx, hash)
 		if err == nil {
+			cached.Response = redact.restoreText(cached.Response)
 			return cached, nil
 		}
 	}
 
 	// Proceed with API call
-	provider, model, err := s.getActiveProviderAndModel()
-	if err != nil {
+	if provider, err = s.credentials.Apply(ctx, module, provider); err != nil {
 		return nil, err
 	}
 	// // Rate limit check
@@ -111,31 +382,123 @@ func (s *SystemPromptService) SendPrompt(
 	// }
 
 	// Make API call
-	response, err := s.callAIAPI(ctx, provider, model, sys, user)
-	if err != nil {
-		return nil, err
+	start := time.Now()
+	response, callErr := s.callAIAPI(ctx, provider, model, sys, user)
+	logEntry.LatencyMs = time.Since(start).Milliseconds()
+
+	if callErr != nil {
+		return nil, s.logRejected(ctx, logEntry, callErr)
 	}
 
-	// Store in database
-	logEntry := &models.AIUsageLog{
-		ModuleName: module,
-		Provider:   provider.Name,
-		PromptHash: hash,
-		Request:    sys + "\n" + user, // Store combined request
-		Response:   response,
+	text, outputHits, guardErr := s.checkGuardrail(ctx, guard, GuardrailStageOutput, response.Text)
+	logEntry.GuardrailHits = append(logEntry.GuardrailHits, outputHits...)
+	logEntry.Response = redact.apply(text)
+	logEntry.Redactions = redact.entities()
+	logEntry.PromptTokens = response.PromptTokens
+	logEntry.CompletionTokens = response.CompletionTokens
+	logEntry.Cost = estimateCost(model, response.PromptTokens, response.CompletionTokens)
+	if guardErr != nil {
+		return nil, s.logRejected(ctx, logEntry, guardErr)
 	}
 
-	if err := s.db.Create(logEntry).Error; err != nil {
+	// Store in database
+	if err := s.db.WithContext(ctx).Create(logEntry).Error; err != nil {
 		return nil, fmt.Errorf("failed to store response: %v", err)
 	}
 
+	logEntry.Response = redact.restoreText(logEntry.Response)
 	return logEntry, nil
 }
 
+// logRejected stores a request that did not produce a response and
+// returns reason. Failures are logged too so error rates can be reported,
+// and guardrail blocks with the rule that fired; either status keeps the
+// entry out of the cache.
+func (s *SystemPromptService) logRejected(ctx context.Context, logEntry *models.AIUsageLog, reason error) error {
+	logEntry.Status = models.UsageStatusError
+	if errors.Is(reason, ErrGuardrailBlocked) {
+		logEntry.Status = models.UsageStatusBlocked
+	}
+	logEntry.Error = reason.Error()
+	if err := s.db.WithContext(ctx).Create(logEntry).Error; err != nil {
+		return fmt.Errorf("failed to store response: %v", err)
+	}
+	return reason
+}
+
+// resolveSystemPrompt returns the final system prompt text for req: the
+// stored or inline prompt with variables and retrieved context rendered in.
+// For stored prompts it also returns the ID of the version that was used.
+// labelled is the version req.Label resolved to, if it has one.
+func (s *SystemPromptService) resolveSystemPrompt(ctx context.Context, req SendRequest, labelled *models.SystemPromptVersion) (string, *uuid.UUID, error) {
+	sys := req.SystemPrompt
+	var decls models.PromptVariables
+	var versionID *uuid.UUID
+	switch {
+	case req.VersionID != "":
+		v, err := s.repo.GetVersionByID(ctx, req.VersionID)
+		if err != nil {
+			return "", nil, fmt.Errorf("prompt version not found: %w", err)
+		}
+		if v.ModuleName != req.Module {
+			return "", nil, fmt.Errorf("prompt version %s does not belong to module %s", req.VersionID, req.Module)
+		}
+		sys, decls, versionID = v.SystemPrompt, v.Variables, &v.ID
+	case labelled != nil:
+		sys, decls, versionID = labelled.SystemPrompt, labelled.Variables, &labelled.ID
+	case req.PromptID != "":
+		sp, err := s.repo.GetByID(ctx, req.PromptID)
+		if err != nil {
+			return "", nil, fmt.Errorf("system prompt not found: %w", err)
+		}
+		if sp.ModuleName != req.Module {
+			return "", nil, fmt.Errorf("system prompt %s does not belong to module %s", req.PromptID, req.Module)
+		}
+		sys, decls, versionID = sp.SystemPrompt, sp.Variables, sp.CurrentVersionID
+	}
+
+	// Inline prompts are sent verbatim unless templating was asked for.
+	stored := req.VersionID != "" || req.PromptID != "" || req.Label != ""
+	if !stored && req.Retrieval == nil && len(req.Variables) == 0 {
+		return sys, nil, nil
+	}
+
+	// Inline prompts declare nothing, so their variables are used as given.
+	values := make(map[string]interface{}, len(req.Variables)+1)
+	if stored {
+		bound, err := bindVariables(decls, req.Variables)
+		if err != nil {
+			return "", nil, err
+		}
+		values = bound
+	} else {
+		for name, value := range req.Variables {
+			values[name] = value
+		}
+	}
+
+	if req.Retrieval != nil {
+		if s.docs == nil {
+			return "", nil, errors.New("retrieval is not enabled")
+		}
+		retrieved, err := s.docs.retrieve(ctx, req.Module, req.UserPrompt, req.Retrieval)
+		if err != nil {
+			return "", nil, err
+		}
+		values[RetrievalContextVar] = retrieved
+		if !strings.Contains(sys, "."+RetrievalContextVar) {
+			sys += "\n\nContext:\n{{." + RetrievalContextVar + "}}"
+		}
+	}
+
+	rendered, err := renderSystemPrompt(sys, values)
+	return rendered, versionID, err
+}
+
 func (s *SystemPromptService) getCachedResponse(ctx context.Context, hash string) (*models.AIUsageLog, error) {
 	var logEntry models.AIUsageLog
-	err := s.db.WithContext(ctx).
-		Where("prompt_hash = ?", hash).
+	err := s.db.WithContext(ctx).Scopes(tenant.Scope(ctx)).
+		Where("prompt_hash = ? AND status = ? AND flagged = ?", hash, models.UsageStatusOK, false).
 		Order("used_at DESC").
 		First(&logEntry).
 		Error
@@ -148,7 +511,7 @@ func (s *SystemPromptService) getCachedResponse(ctx context.Context, hash string
 
 func (s *SystemPromptService) checkRateLimit(ctx context.Context, module, provider string) error {
 	var limit models.RateLimit
-	result := s.db.WithContext(ctx).
+	result := s.db.WithContext(ctx).Scopes(tenant.Scope(ctx)).
 		Where("module_name = ? AND provider = ?", module, provider).
 		First(&limit)
 
@@ -156,7 +519,7 @@ func (s *SystemPromptService) checkRateLimit(ctx context.Context, module, provid
 	if result.Error == nil {
 		var count int64
 		start := time.Now().Add(-time.Duration(limit.PerSeconds) * time.Second)
-		err := s.db.Model(&models.AIUsageLog{}).
+		err := s.db.WithContext(ctx).Scopes(tenant.Scope(ctx)).Model(&models.AIUsageLog{}).
 			Where("module_name = ? AND provider = ? AND used_at >= ?", module, provider, start).
 			Count(&count).
 			Error
@@ -173,94 +536,118 @@ func (s *SystemPromptService) checkRateLimit(ctx context.Context, module, provid
 
 	return nil
 }
+
+// completion is the parsed result of one provider call.
+type completion struct {
+	Text             string
+	PromptTokens     int
+	CompletionTokens int
+}
+
 func (s *SystemPromptService) callAIAPI(
 	ctx context.Context,
 	provider *config.ProviderConfig,
 	model *config.ModelConfig,
 	sys, user string,
-) (string, error) {
-	// Construct request body using template
-	tmpl, err := template.New("request").Parse(model.Config)
+) (*completion, error) {
+	body, err := renderRequest(model, sys, user)
 	if err != nil {
-		return "", fmt.Errorf("invalid request template: %w", err)
+		return nil, err
 	}
-
-	var bodyBuf bytes.Buffer
-	err = tmpl.Execute(&bodyBuf, struct {
-		SystemPrompt string
-		UserPrompt   string
-	}{sys, user})
+	responseBody, err := postToProvider(ctx, provider, completionURL(provider, model), bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("template execution failed: %w", err)
+		return nil, err
 	}
 
-	// Create HTTP request
-	url := fmt.Sprintf("%s%s:generateContent", provider.BaseURL, model.Name)
-	if provider.AuthMethod == "query_param" {
-		url += fmt.Sprintf("?key=%s", provider.APIKey)
+	var result interface{}
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, &bodyBuf)
+	text, err := s.extractResponse(result, model.ResponsePath)
 	if err != nil {
-		return "", fmt.Errorf("request creation failed: %w", err)
+		return nil, err
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	if provider.AuthMethod == "header" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", provider.APIKey))
+	out := &completion{
+		Text:             text,
+		PromptTokens:     extractTokens(result, model.PromptTokensPath, sys+user),
+		CompletionTokens: extractTokens(result, model.CompletionTokensPath, text),
 	}
+	return out, nil
+}
 
-	// Execute request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+// renderRequest builds the request body of model from its template.
+func renderRequest(model *config.ModelConfig, sys, user string) ([]byte, error) {
+	tmpl, err := template.New("request").Parse(model.Config)
 	if err != nil {
-		return "", fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("invalid request template: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Handle errors
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API error (%d): %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response
-	responseBody, err := io.ReadAll(resp.Body)
+	var bodyBuf bytes.Buffer
+	err = tmpl.Execute(&bodyBuf, struct {
+		SystemPrompt string
+		UserPrompt   string
+	}{sys, user})
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("template execution failed: %w", err)
 	}
+	return bodyBuf.Bytes(), nil
+}
 
-	return s.extractResponse(responseBody, model.ResponsePath)
+func completionURL(provider *config.ProviderConfig, model *config.ModelConfig) string {
+	return fmt.Sprintf("%s%s:generateContent", provider.BaseURL, model.Name)
 }
 
-func (s *SystemPromptService) extractResponse(body []byte, path string) (string, error) {
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("invalid JSON response: %w", err)
+func (s *SystemPromptService) extractResponse(result interface{}, path string) (string, error) {
+	current, err := lookupPath(result, path)
+	if err != nil {
+		return "", err
 	}
+	if str, ok := current.(string); ok {
+		return str, nil
+	}
+	return "", fmt.Errorf("response text not found at path")
+}
 
+// lookupPath walks a dot-separated path such as "candidates.0.content"
+// through decoded JSON.
+func lookupPath(result interface{}, path string) (interface{}, error) {
 	// Simple JSON path implementation
 	parts := strings.Split(path, ".")
 	var current interface{} = result
 
-	for _, part := range parts {
+	for i, part := range parts {
 		switch v := current.(type) {
 		case map[string]interface{}:
 			current = v[part]
 		case []interface{}:
 			index, err := strconv.Atoi(part)
-			if err != nil || index >= len(v) {
-				return "", fmt.Errorf("invalid array index in path")
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("invalid array index %q in path", part)
 			}
 			current = v[index]
 		default:
-			return "", fmt.Errorf("invalid response structure")
+			return nil, fmt.Errorf("invalid response structure at %q", strings.Join(parts[:i+1], "."))
 		}
 	}
+	return current, nil
+}
 
-	if str, ok := current.(string); ok {
-		return str, nil
+// extractTokens reads a token count from the response, falling back to a
+// rough four-characters-per-token estimate of text.
+func extractTokens(result interface{}, path, text string) int {
+	if path != "" {
+		if n, err := lookupPath(result, path); err == nil {
+			if f, ok := n.(float64); ok {
+				return int(f)
+			}
+		}
 	}
-	return "", fmt.Errorf("response text not found at path")
+	return (len(text) + 3) / 4
+}
+
+func estimateCost(model *config.ModelConfig, promptTokens, completionTokens int) float64 {
+	return float64(promptTokens)/1000*model.InputCostPer1K +
+		float64(completionTokens)/1000*model.OutputCostPer1K
 }
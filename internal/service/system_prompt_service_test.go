@@ -0,0 +1,171 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemPromptService_SendRendersVariables(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+
+	sp, err := svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Greet {{.customer_name}} in {{.language}}.", Variables: models.PromptVariables{
+		{Name: "customer_name", Type: models.VariableTypeString, Required: true},
+		{Name: "language", Type: models.VariableTypeString, Default: "English"},
+	}}, "", "")
+	require.NoError(t, err)
+
+	send := func(vars map[string]interface{}) (*models.AIUsageLog, error) {
+		return svc.SendPrompt(ctx, service.SendRequest{
+			Module:     "support",
+			PromptID:   sp.ID.String(),
+			UserPrompt: "hello",
+			Variables:  vars,
+		})
+	}
+
+	first, err := send(map[string]interface{}{"customer_name": "Ada"})
+	require.NoError(t, err)
+	assert.Contains(t, first.Response, "Greet Ada in English.")
+
+	second, err := send(map[string]interface{}{"customer_name": "Grace", "language": "French"})
+	require.NoError(t, err)
+	assert.Contains(t, second.Response, "Greet Grace in French.")
+	assert.NotEqual(t, first.PromptHash, second.PromptHash)
+
+	_, err = send(map[string]interface{}{"language": "French", "tone": "formal"})
+	var varErr *service.PromptVariableError
+	require.ErrorAs(t, err, &varErr)
+	assert.Equal(t, []string{"customer_name"}, varErr.Missing)
+	assert.Equal(t, []string{"tone"}, varErr.Unknown)
+
+	_, err = send(map[string]interface{}{"customer_name": 42})
+	require.ErrorAs(t, err, &varErr)
+	assert.Len(t, varErr.Invalid, 1)
+
+	_, err = svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Hi {{.name"}, "", "")
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt)
+
+	// Templates may only use declared variables.
+	_, err = svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model",
+		SystemPrompt: "Hi {{.name}}{{if .vip}} and {{$.title}}{{end}}{{with .team}}{{.lead}}{{end}}"}, "", "")
+	require.ErrorIs(t, err, service.ErrInvalidPrompt)
+	assert.Contains(t, err.Error(), "undeclared variables: name, team, title, vip")
+	_, err = svc.Update(ctx, sp.ID.String(), service.PromptFields{SystemPrompt: "Greet {{.customer}}."}, nil, "", "")
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt)
+
+	// Inline prompts use the variables as given; a missing one is the
+	// caller's mistake.
+	inline := service.SendRequest{Module: "support", SystemPrompt: "Greet {{.who}}.", UserPrompt: "hello",
+		Variables: map[string]interface{}{"who": "Linus"}}
+	out, err := svc.SendPrompt(ctx, inline)
+	require.NoError(t, err)
+	assert.Contains(t, out.Response, "Greet Linus.")
+	inline.Variables = map[string]interface{}{"whom": "Linus"}
+	_, err = svc.SendPrompt(ctx, inline)
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt)
+}
+
+func TestSystemPromptService_VersionHistory(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+
+	sp, err := svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Be brief."}, "ada", "first draft")
+	require.NoError(t, err)
+	assert.Equal(t, 1, sp.Version)
+
+	_, err = svc.Update(ctx, sp.ID.String(), service.PromptFields{SystemPrompt: "Be brief.\nBe kind."}, nil, "grace", "tone")
+	require.NoError(t, err)
+
+	sent, err := svc.SendPrompt(ctx, service.SendRequest{Module: "support", PromptID: sp.ID.String(), UserPrompt: "hi"})
+	require.NoError(t, err)
+
+	versions, err := svc.ListVersions(ctx, sp.ID.String())
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+	assert.Equal(t, "grace", versions[0].Author)
+	assert.Equal(t, "tone", versions[0].ChangeNote)
+	require.NotNil(t, sent.PromptVersionID)
+	assert.Equal(t, versions[0].ID, *sent.PromptVersionID)
+
+	diff, err := svc.DiffVersions(ctx, sp.ID.String(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []service.DiffLine{{Op: " ", Text: "Be brief."}, {Op: "+", Text: "Be kind."}}, diff.Diff)
+
+	rolledBack, err := svc.Rollback(ctx, sp.ID.String(), 1, "ada", "")
+	require.NoError(t, err)
+	assert.Equal(t, 3, rolledBack.Version)
+	assert.Equal(t, "Be brief.", rolledBack.SystemPrompt)
+
+	versions, err = svc.ListVersions(ctx, sp.ID.String())
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+	assert.Equal(t, "rollback to version 1", versions[0].ChangeNote)
+}
+
+func TestSystemPromptService_LabelsAndPromotion(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	svc := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	ctx := context.Background()
+
+	sp, err := svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Version one."}, "ada", "")
+	require.NoError(t, err)
+	_, err = svc.Update(ctx, sp.ID.String(), service.PromptFields{SystemPrompt: "Version two."}, nil, "ada", "")
+	require.NoError(t, err)
+
+	_, err = svc.SetLabel(ctx, sp.ID.String(), models.LabelProduction, 1, "ada")
+	require.NoError(t, err)
+	_, err = svc.SetLabel(ctx, sp.ID.String(), models.LabelStaging, 2, "ada")
+	require.NoError(t, err)
+
+	sendLabel := func(label string) string {
+		out, err := svc.SendPrompt(ctx, service.SendRequest{Module: "support", Label: label, UserPrompt: "hi"})
+		require.NoError(t, err)
+		return out.Response
+	}
+	assert.Contains(t, sendLabel(models.LabelProduction), "Version one.")
+	assert.Contains(t, sendLabel(models.LabelStaging), "Version two.")
+	assert.Contains(t, sendLabel(models.LabelDraft), "Version two.")
+
+	promoted, err := svc.Promote(ctx, sp.ID.String(), models.LabelStaging, models.LabelProduction, "grace")
+	require.NoError(t, err)
+	assert.Equal(t, 2, promoted.Version)
+	assert.Contains(t, sendLabel(models.LabelProduction), "Version two.")
+
+	_, err = svc.SetLabel(ctx, sp.ID.String(), models.LabelDraft, 1, "ada")
+	assert.ErrorIs(t, err, service.ErrInvalidPrompt)
+
+	// Labels of a deleted prompt are ignored until it is restored.
+	other, err := svc.Create(ctx, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Other."}, "ada", "")
+	require.NoError(t, err)
+	_, err = svc.SetLabel(ctx, other.ID.String(), models.LabelProduction, 1, "ada")
+	require.NoError(t, err)
+	_, err = svc.SendPrompt(ctx, service.SendRequest{Module: "support", Label: models.LabelProduction, UserPrompt: "hi"})
+	assert.ErrorContains(t, err, "pass prompt_id")
+	require.NoError(t, svc.Delete(ctx, other.ID.String()))
+	assert.Contains(t, sendLabel(models.LabelProduction), "Version two.")
+	_, err = svc.SendPrompt(ctx, service.SendRequest{Module: "support", PromptID: other.ID.String(), Label: models.LabelProduction, UserPrompt: "hi"})
+	assert.Error(t, err)
+	_, err = svc.SetLabel(ctx, other.ID.String(), models.LabelStaging, 1, "ada")
+	assert.Error(t, err)
+	_, err = svc.Restore(ctx, other.ID.String())
+	require.NoError(t, err)
+	_, err = svc.SendPrompt(ctx, service.SendRequest{Module: "support", Label: models.LabelProduction, UserPrompt: "hi"})
+	assert.ErrorContains(t, err, "pass prompt_id")
+
+	_, err = svc.SendPrompt(ctx, service.SendRequest{Module: "billing", Label: models.LabelProduction, UserPrompt: "hi"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+)
+
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type VersionDiff struct {
+	From    int                    `json:"from"`
+	To      int                    `json:"to"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+	Diff    []DiffLine             `json:"diff"`
+}
+
+// recordVersion snapshots sp as its next version and points sp at it.
+// It must run inside the caller's transaction.
+func (s *SystemPromptService) recordVersion(ctx context.Context, sp *models.SystemPrompt, author, note string) error {
+	v := &models.SystemPromptVersion{
+		SystemPromptID: sp.ID,
+		Version:        sp.Version + 1,
+		ModuleName:     sp.ModuleName,
+		ModelName:      sp.ModelName,
+		Provider:       sp.Provider,
+		SystemPrompt:   sp.SystemPrompt,
+		Variables:      sp.Variables,
+		Author:         author,
+		ChangeNote:     note,
+	}
+	if err := s.repo.CreateVersion(ctx, v); err != nil {
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+	sp.Version = v.Version
+	sp.CurrentVersionID = &v.ID
+	if err := s.repo.Update(ctx, sp); err != nil {
+		return err
+	}
+	return s.repo.UpsertLabel(ctx, labelFor(v, models.LabelDraft, author))
+}
+
+func (s *SystemPromptService) ListVersions(ctx context.Context, id string) ([]models.SystemPromptVersion, error) {
+	return s.repo.ListVersions(ctx, id)
+}
+
+// DiffVersions compares two versions of a prompt: a line diff of the prompt
+// text plus any model, provider or variable changes.
+func (s *SystemPromptService) DiffVersions(ctx context.Context, id string, from, to int) (*VersionDiff, error) {
+	a, err := s.repo.GetVersion(ctx, id, from)
+	if err != nil {
+		return nil, fmt.Errorf("version %d not found: %w", from, err)
+	}
+	b, err := s.repo.GetVersion(ctx, id, to)
+	if err != nil {
+		return nil, fmt.Errorf("version %d not found: %w", to, err)
+	}
+
+	diff := &VersionDiff{
+		From:    from,
+		To:      to,
+		Changes: map[string]FieldChange{},
+		Diff:    diffLines(a.SystemPrompt, b.SystemPrompt),
+	}
+	if a.ModelName != b.ModelName {
+		diff.Changes["model_name"] = FieldChange{a.ModelName, b.ModelName}
+	}
+	if a.Provider != b.Provider {
+		diff.Changes["provider"] = FieldChange{a.Provider, b.Provider}
+	}
+	av, _ := json.Marshal(a.Variables)
+	bv, _ := json.Marshal(b.Variables)
+	if string(av) != string(bv) {
+		diff.Changes["variables"] = FieldChange{a.Variables, b.Variables}
+	}
+	return diff, nil
+}
+
+// Rollback restores the content of an earlier version. The restore is itself
+// recorded as a new version so history stays append-only.
+func (s *SystemPromptService) Rollback(ctx context.Context, id string, version int, author, note string) (*models.SystemPrompt, error) {
+	var sp *models.SystemPrompt
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		sp, err = s.repo.GetByIDForUpdate(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkEditable(txCtx, sp); err != nil {
+			return err
+		}
+		before := snapshot(sp)
+		target, err := s.repo.GetVersion(txCtx, id, version)
+		if err != nil {
+			return fmt.Errorf("version %d not found: %w", version, err)
+		}
+
+		sp.SystemPrompt = target.SystemPrompt
+		sp.Variables = target.Variables
+		sp.ModelName = target.ModelName
+		sp.Provider = target.Provider
+		if note == "" {
+			note = fmt.Sprintf("rollback to version %d", version)
+		}
+		if err := s.recordVersion(txCtx, sp, author, note); err != nil {
+			return err
+		}
+		return s.audit.Record(txCtx, models.AuditActionUpdate, models.AuditResourcePrompt, id, before, sp)
+	})
+	return sp, err
+}
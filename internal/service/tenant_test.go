@@ -0,0 +1,56 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	usage := service.NewUsageService(repository.NewUsageRepo(db))
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	sp, err := prompts.Create(acme, "support", service.PromptFields{Provider: "openai", ModelName: "fake-model", SystemPrompt: "Be brief."}, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", sp.TenantID)
+
+	listed, err := prompts.Get(globex)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+	_, err = prompts.GetByID(globex, sp.ID.String())
+	assert.Error(t, err)
+	_, err = prompts.SendPrompt(globex, service.SendRequest{Module: "support", PromptID: sp.ID.String(), UserPrompt: "hi"})
+	assert.Error(t, err, "another tenant's prompt must not resolve")
+
+	req := service.SendRequest{Module: "support", SystemPrompt: "Be brief.", UserPrompt: "hi"}
+	first, err := prompts.SendPrompt(acme, req)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", first.TenantID)
+	other, err := prompts.SendPrompt(globex, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID, "cache must not be shared across tenants")
+	assert.Equal(t, first.PromptHash, other.PromptHash)
+
+	_, err = usage.AddFeedback(globex, first.ID.String(), service.FeedbackInput{Thumbs: models.ThumbsDown})
+	assert.Error(t, err)
+
+	report, err := usage.Report(acme, repository.UsageFilter{Module: "support"})
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, int64(1), report[0].Requests)
+
+	report, err = usage.Report(tenant.WithTenant(context.Background(), "initech"), repository.UsageFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, report)
+}
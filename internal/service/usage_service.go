@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+)
+
+// ErrInvalidFeedback wraps validation failures on submitted feedback.
+var ErrInvalidFeedback = errors.New("invalid feedback")
+
+type UsageService struct {
+	repo *repository.UsageRepo
+}
+
+func NewUsageService(repo *repository.UsageRepo) *UsageService {
+	return &UsageService{repo: repo}
+}
+
+type FeedbackInput struct {
+	Thumbs  string
+	Score   *float64
+	Comment string
+	Tags    []string
+}
+
+type FeedbackSummary struct {
+	Count      int64    `json:"count"`
+	ThumbsUp   int64    `json:"thumbs_up"`
+	ThumbsDown int64    `json:"thumbs_down"`
+	AvgScore   *float64 `json:"avg_score"`
+}
+
+func summarizeFeedback(st repository.FeedbackStats) FeedbackSummary {
+	return FeedbackSummary{
+		Count:      st.FeedbackCount,
+		ThumbsUp:   st.ThumbsUp,
+		ThumbsDown: st.ThumbsDown,
+		AvgScore:   st.AvgScore,
+	}
+}
+
+type UsageReportRow struct {
+	ModuleName       string          `json:"module_name"`
+	Provider         string          `json:"provider"`
+	ModelName        string          `json:"model_name"`
+	Kind             string          `json:"kind"`
+	Requests         int64           `json:"requests"`
+	Errors           int64           `json:"errors"`
+	AvgLatencyMs     float64         `json:"avg_latency_ms"`
+	PromptTokens     int64           `json:"prompt_tokens"`
+	CompletionTokens int64           `json:"completion_tokens"`
+	TotalCost        float64         `json:"total_cost"`
+	Feedback         FeedbackSummary `json:"feedback"`
+}
+
+// AddFeedback records feedback on a logged response. A thumbs down also
+// flags the response so it is no longer served from the cache.
+func (s *UsageService) AddFeedback(ctx context.Context, usageID string, in FeedbackInput) (*models.Feedback, error) {
+	switch in.Thumbs {
+	case "", models.ThumbsUp, models.ThumbsDown:
+	default:
+		return nil, fmt.Errorf("%w: thumbs must be %q or %q", ErrInvalidFeedback, models.ThumbsUp, models.ThumbsDown)
+	}
+	if in.Score != nil && (math.IsNaN(*in.Score) || math.IsInf(*in.Score, 0)) {
+		return nil, fmt.Errorf("%w: score must be a finite number", ErrInvalidFeedback)
+	}
+	if in.Thumbs == "" && in.Score == nil && in.Comment == "" && len(in.Tags) == 0 {
+		return nil, fmt.Errorf("%w: feedback is empty", ErrInvalidFeedback)
+	}
+
+	var fb *models.Feedback
+	err := s.repo.WithTransaction(ctx, func(txCtx context.Context) error {
+		entry, err := s.repo.GetLog(txCtx, usageID)
+		if err != nil {
+			return err
+		}
+		fb = &models.Feedback{
+			UsageLogID: entry.ID,
+			ModuleName: entry.ModuleName,
+			Thumbs:     in.Thumbs,
+			Score:      in.Score,
+			Comment:    in.Comment,
+			Tags:       in.Tags,
+		}
+		if err := s.repo.CreateFeedback(txCtx, fb); err != nil {
+			return err
+		}
+		if in.Thumbs == models.ThumbsDown {
+			return s.repo.Flag(txCtx, entry)
+		}
+		return nil
+	})
+	return fb, err
+}
+
+func (s *UsageService) GetLog(ctx context.Context, id string) (*models.AIUsageLog, error) {
+	return s.repo.GetLog(ctx, id)
+}
+
+func (s *UsageService) ListFeedback(ctx context.Context, usageID string) ([]models.Feedback, error) {
+	return s.repo.ListFeedback(ctx, usageID)
+}
+
+// Report aggregates usage and feedback per module, provider, model and kind.
+func (s *UsageService) Report(ctx context.Context, filter repository.UsageFilter) ([]UsageReportRow, error) {
+	usage, err := s.repo.UsageStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	feedback, err := s.repo.FeedbackStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ module, provider, model, kind string }
+	byKey := make(map[key]repository.FeedbackStats, len(feedback))
+	for _, f := range feedback {
+		byKey[key{f.ModuleName, f.Provider, f.ModelName, f.Kind}] = f.FeedbackStats
+	}
+
+	rows := make([]UsageReportRow, len(usage))
+	for i, u := range usage {
+		rows[i] = UsageReportRow{
+			ModuleName:       u.ModuleName,
+			Provider:         u.Provider,
+			ModelName:        u.ModelName,
+			Kind:             u.Kind,
+			Requests:         u.Requests,
+			Errors:           u.Errors,
+			AvgLatencyMs:     u.AvgLatencyMs,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			TotalCost:        u.TotalCost,
+			Feedback:         summarizeFeedback(byKey[key{u.ModuleName, u.Provider, u.ModelName, u.Kind}]),
+		}
+	}
+	return rows, nil
+}
+
+const defaultResealBatch = 500
+
+// ResealLogs rewrites the request and response bodies of usage logs of
+// every tenant that are not stored as the current field encryption
+// settings dictate: sealed with an older key, in plaintext while
+// encryption is on, or sealed while it is off. It works through batch
+// logs at a time and returns how many it rewrote.
+func (s *UsageService) ResealLogs(ctx context.Context, batch int) (int, error) {
+	if batch <= 0 {
+		batch = defaultResealBatch
+	}
+	want := ""
+	if version, seal := models.FieldEncryption(); seal {
+		want = models.SealedPrefix(version)
+	}
+	done, after := 0, ""
+	for {
+		logs, err := s.repo.ListToReseal(ctx, after, want, batch)
+		if err != nil || len(logs) == 0 {
+			return done, err
+		}
+		for i := range logs {
+			if err := s.repo.SaveBodies(ctx, &logs[i]); err != nil {
+				return done, fmt.Errorf("usage log %s: %w", logs[i].ID, err)
+			}
+			done++
+		}
+		after = logs[len(logs)-1].ID.String()
+	}
+}
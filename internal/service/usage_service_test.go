@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/config"
+	"github.com/abeselom-personal/go-ai-service/internal/encryption"
+	models "github.com/abeselom-personal/go-ai-service/internal/model"
+	"github.com/abeselom-personal/go-ai-service/internal/repository"
+	"github.com/abeselom-personal/go-ai-service/internal/service"
+	"github.com/abeselom-personal/go-ai-service/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageService_NegativeFeedbackEvictsCache(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	usage := service.NewUsageService(repository.NewUsageRepo(db))
+	ctx := context.Background()
+
+	req := service.SendRequest{Module: "support", SystemPrompt: "Be brief.", UserPrompt: "hi"}
+	first, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	cached, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, cached.ID, "second call should be served from cache")
+
+	score := 1.0
+	_, err = usage.AddFeedback(ctx, first.ID.String(), service.FeedbackInput{
+		Thumbs: models.ThumbsDown, Score: &score, Comment: "wrong answer", Tags: []string{"accuracy"},
+	})
+	require.NoError(t, err)
+
+	fresh, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, fresh.ID, "flagged response must not be served from cache")
+
+	_, err = usage.AddFeedback(ctx, fresh.ID.String(), service.FeedbackInput{Thumbs: "sideways"})
+	assert.ErrorIs(t, err, service.ErrInvalidFeedback)
+
+	report, err := usage.Report(ctx, repository.UsageFilter{Module: "support"})
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, int64(2), report[0].Requests)
+	assert.Equal(t, int64(1), report[0].Feedback.ThumbsDown)
+	require.NotNil(t, report[0].Feedback.AvgScore)
+	assert.Equal(t, 1.0, *report[0].Feedback.AvgScore)
+}
+
+func TestUsageService_NegativeFeedbackFlagsEveryCopy(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	usage := service.NewUsageService(repository.NewUsageRepo(db))
+	ctx := context.Background()
+
+	req := service.SendRequest{Module: "support", SystemPrompt: "Be brief.", UserPrompt: "hi", BypassCache: true}
+	older, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	newer, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, older.PromptHash, newer.PromptHash)
+
+	_, err = usage.AddFeedback(ctx, older.ID.String(), service.FeedbackInput{Thumbs: models.ThumbsDown})
+	require.NoError(t, err)
+
+	req.BypassCache = false
+	fresh, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, older.ID, fresh.ID)
+	assert.NotEqual(t, newer.ID, fresh.ID, "a copy of a flagged response must not be served from cache")
+}
+
+func TestUsageService_EncryptedLogsAndKeyRotation(t *testing.T) {
+	db := testutil.NewSQLiteDB(t, models.All()...)
+	cfg := newTestConfig(newFakeProvider(t).URL)
+	prompts := service.NewSystemPromptService(db, repository.NewSystemPromptRepo(db), cfg, nil)
+	usage := service.NewUsageService(repository.NewUsageRepo(db))
+	ctx := context.Background()
+	t.Cleanup(func() { models.UseFieldEncryption(nil, false) })
+
+	keyring := func(sec config.SecurityConfig) *encryption.Keyring {
+		keys, err := encryption.NewKeyring(sec)
+		require.NoError(t, err)
+		return keys
+	}
+	const keyV1, keyV2 = "0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"
+	type stored struct{ Request, Response, UserPrompt, PromptHash string }
+	raw := func(id uuid.UUID) stored {
+		var row stored
+		require.NoError(t, db.Raw("SELECT request, response, user_prompt, prompt_hash FROM ai_usage_logs WHERE id = ?",
+			id).Scan(&row).Error)
+		return row
+	}
+
+	plainReq := service.SendRequest{Module: "support", SystemPrompt: "Be brief.", UserPrompt: "before encryption"}
+	plain, err := prompts.SendPrompt(ctx, plainReq)
+	require.NoError(t, err)
+	assert.Zero(t, models.SealedAs(raw(plain.ID).Request))
+
+	models.UseFieldEncryption(keyring(config.SecurityConfig{EncryptionKey: keyV1, EncryptionKeyVersion: 1}), true)
+	req := service.SendRequest{Module: "support", SystemPrompt: "Be brief.", UserPrompt: "my email is ada@example.com"}
+	first, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	assert.Contains(t, first.Response, "ada@example.com", "callers get plaintext back")
+
+	row := raw(first.ID)
+	for _, v := range []string{row.Request, row.Response, row.UserPrompt} {
+		assert.Equal(t, 1, models.SealedAs(v))
+		assert.NotContains(t, v, "ada@example.com")
+	}
+	assert.Equal(t, first.PromptHash, row.PromptHash)
+
+	cached, err := prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, cached.ID, "cache lookups work on the plaintext hash")
+	assert.Equal(t, first.Response, cached.Response)
+
+	n, err := usage.ResealLogs(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the plaintext log needs sealing")
+	assert.Equal(t, 1, models.SealedAs(raw(plain.ID).Response))
+
+	models.UseFieldEncryption(keyring(config.SecurityConfig{
+		EncryptionKey: keyV2, EncryptionKeyVersion: 2,
+		PreviousKeys: []config.PreviousKey{{Version: 1, Key: keyV1}},
+	}), true)
+	n, err = usage.ResealLogs(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = usage.ResealLogs(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, 2, models.SealedAs(raw(first.ID).Request))
+
+	models.UseFieldEncryption(keyring(config.SecurityConfig{EncryptionKey: keyV2, EncryptionKeyVersion: 2}), true)
+	cached, err = prompts.SendPrompt(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.Response, cached.Response, "rows are readable without the retired key")
+
+	// Sealed bodies are bound to their tenant, so moving them to another
+	// tenant's row does not reveal them.
+	require.NoError(t, db.Exec("UPDATE ai_usage_logs SET tenant_id = ? WHERE id = ?", "acme", first.ID).Error)
+	assert.Error(t, db.First(&models.AIUsageLog{}, "id = ?", first.ID).Error)
+}
@@ -0,0 +1,85 @@
+// Package tenant carries the caller's tenant through request contexts and
+// keeps database access within it.
+package tenant
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Default is the tenant of single-tenant installs, of requests made while
+// auth is disabled and of rows created before tenancy existed.
+const Default = "default"
+
+type contextKey struct{}
+
+// WithTenant returns a copy of ctx acting on behalf of tenant id.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ctx acts for, or Default.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
+
+// Detach returns a background context for work that outlives the request,
+// keeping only its tenant.
+func Detach(ctx context.Context) context.Context {
+	return WithTenant(context.Background(), FromContext(ctx))
+}
+
+// Scope limits a query to the tenant of ctx. The column is qualified with
+// the statement's table so it also works on joins.
+func Scope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	id := FromContext(ctx)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"},
+			Value:  id,
+		})
+	}
+}
+
+// Register installs a create callback that stamps the tenant of the
+// statement's context on every new row with an empty TenantID, including
+// rows created through associations.
+func Register(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("tenant:assign", assign)
+}
+
+func assign(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("TenantID")
+	if field == nil {
+		return
+	}
+	id := FromContext(db.Statement.Context)
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setIfEmpty(db, field, reflect.Indirect(rv.Index(i)), id)
+		}
+	case reflect.Struct:
+		setIfEmpty(db, field, rv, id)
+	}
+}
+
+func setIfEmpty(db *gorm.DB, field *schema.Field, rv reflect.Value, id string) {
+	if _, zero := field.ValueOf(db.Statement.Context, rv); zero {
+		if err := field.Set(db.Statement.Context, rv, id); err != nil {
+			db.AddError(err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package testutil holds helpers shared by the package tests.
+package testutil
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/abeselom-personal/go-ai-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const driverName = "sqlite3_ai_service"
+
+var registerOnce sync.Once
+
+// NewSQLiteDB opens an in-memory SQLite database private to the test and
+// migrates the given models. gen_random_uuid() is registered so the
+// Postgres-style primary key defaults work, and new rows are stamped with
+// their context's tenant as in production.
+func NewSQLiteDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	registerOnce.Do(func() {
+		sql.Register(driverName, &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				return conn.RegisterFunc("gen_random_uuid", func() string {
+					return uuid.NewString()
+				}, false)
+			},
+		})
+	})
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", name)
+	db, err := gorm.Open(sqlite.New(sqlite.Config{DriverName: driverName, DSN: dsn}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, tenant.Register(db))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, m := range models {
+		require.NoError(t, parenthesizeDefaults(db, m))
+	}
+	require.NoError(t, db.AutoMigrate(models...))
+	return db
+}
+
+// parenthesizeDefaults wraps function call defaults such as
+// gen_random_uuid() in parentheses, which SQLite requires of expressions
+// in a DEFAULT clause and Postgres accepts either way. The change is made
+// to the schema db caches for m, so the models keep their Postgres tags.
+func parenthesizeDefaults(db *gorm.DB, m interface{}) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(m); err != nil {
+		return err
+	}
+	for _, field := range stmt.Schema.Fields {
+		if strings.HasSuffix(field.DefaultValue, ")") && !strings.HasPrefix(field.DefaultValue, "(") {
+			field.DefaultValue = "(" + field.DefaultValue + ")"
+		}
+	}
+	return nil
+}